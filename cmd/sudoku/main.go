@@ -1,32 +1,312 @@
 //+build !appengine
 
 // This is an example for using the sudoku package. It reads one sudoku from
-// stdin and prints out the solution, if any. Otherwise, it prints a message
-// and exits with code 1.
+// stdin and acts on it according to the subcommand given:
+//
+//	sudoku [solve]   prints the solution, if any
+//	sudoku explain   prints the steps a human would take to solve it
+//	sudoku rate      rates the difficulty of every sudoku read
+//	sudoku generate  prints a new sudoku, see -help for the options
+//	sudoku minimize  removes clues as long as the solution stays unique
+//
+// If there is no solution, it prints a message and exits with code 1. Solve
+// and explain also print a minimal set of clues that conflict with each
+// other, see sudoku.Sudoku.ConflictingClues. All subcommands reading sudokus
+// accept -box to read 4x4 (-box 2), 16x16 (-box 4) or 25x25 (-box 5) sudokus
+// instead of standard ones, and -variant to add the rules of variants such
+// as X-Sudoku (-variant x), Windoku (-variant windoku) or anti-knight
+// sudokus (-variant antiknight). Jigsaw sudokus are read with -regions,
+// killer sudokus with -cages, additional regions with -extra-regions, Kropki
+// dots or XV markers with -markers, thermometers, arrows, greater-than
+// signs, renban, German whispers or palindrome lines with -lines and
+// sandwich clues with -sandwiches, see sudoku.Jigsaw, sudoku.Killer,
+// sudoku.ExtraRegions, sudoku.Markers, sudoku.Lines and sudoku.Sandwiches
+// for the formats of the files. Solve reads samurai sudokus with -samurai.
+// With -strict, input that is only accepted by ignoring stray characters is
+// rejected, see sudoku.ParseOptions.Strict.
+// In contrast to the other subcommands, rate reads sudokus until the end of
+// the input, and prints one rating per line, unless -strict is given, which
+// allows a single sudoku only. It stops at the first sudoku that can't be
+// read. Generate doesn't read anything.
 package main
 
 import (
 	"bufio"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/thriqon/sudoku"
 )
 
+var commands = map[string]func(args []string) int{
+	"solve":    solve,
+	"explain":  explain,
+	"rate":     rate,
+	"generate": generate,
+	"minimize": minimize,
+}
+
 func main() {
-	sudoku, err := sudoku.ParseReader(bufio.NewReader(os.Stdin))
+	name, args := "solve", os.Args[1:]
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintln(os.Stderr, "usage: sudoku [solve|explain|rate|generate|minimize] < sudoku.txt")
+		os.Exit(2)
+	}
+	os.Exit(cmd(args))
+}
+
+// parseOptions adds the flags controlling how sudokus are read to fs.
+func parseOptions(fs *flag.FlagSet) *sudoku.ParseOptions {
+	opts := &sudoku.ParseOptions{}
+	fs.IntVar(&opts.BoxSize, "box", 3, "size of the boxes, from 2 (4x4 sudokus) to 5 (25x25 sudokus)")
+	fs.Var(variantFlag{&opts.Variant}, "variant", "additional rules: x, windoku, antiknight, antiking or nonconsecutive, several can be joined by +")
+	fs.Var(fileFlag{&opts.Regions}, "regions", "file with a grid of region labels replacing the boxes, for jigsaw sudokus")
+	fs.Var(fileFlag{&opts.Cages}, "cages", "file with the cages of a killer sudoku")
+	fs.Var(fileFlag{&opts.ExtraRegions}, "extra-regions", "file with a grid of labels for additional regions")
+	fs.Var(fileFlag{&opts.Markers}, "markers", "file with Kropki dots and XV markers between squares")
+	fs.Var(fileFlag{&opts.Lines}, "lines", "JSON file with thermometers, arrows, greater-than signs and other lines")
+	fs.Var(fileFlag{&opts.Sandwiches}, "sandwiches", "file with the clues of a sandwich sudoku")
+	fs.BoolVar(&opts.Strict, "strict", false, "reject stray characters and anything after the last square")
+	return opts
+}
+
+// fileFlag reads the contents of the file given.
+type fileFlag struct {
+	contents *string
+}
+
+func (f fileFlag) String() string {
+	return ""
+}
+
+func (f fileFlag) Set(name string) error {
+	contents, err := ioutil.ReadFile(name)
+	*f.contents = string(contents)
+	return err
+}
+
+// variantFlag sets a variant given by its name.
+type variantFlag struct {
+	v *sudoku.Variant
+}
+
+func (f variantFlag) String() string {
+	if f.v == nil {
+		return ""
+	}
+	return f.v.String()
+}
+
+func (f variantFlag) Set(name string) error {
+	v, ok := sudoku.ParseVariant(name)
+	if !ok {
+		return fmt.Errorf("unknown variant %s", name)
+	}
+	*f.v = v
+	return nil
+}
+
+func solve(args []string) int {
+	fs := flag.NewFlagSet("solve", flag.ExitOnError)
+	opts := parseOptions(fs)
+	samurai := fs.Bool("samurai", false, "read a samurai sudoku of five overlapping grids")
+	fs.Parse(args)
+
+	if *samurai {
+		return solveSamurai()
+	}
+
+	s, err := opts.ParseReader(bufio.NewReader(os.Stdin))
 	if err != nil {
 		fmt.Println(err)
-		return
+		if errors.Is(err, sudoku.ErrConflict) {
+			printConflict(s)
+		}
+		return 0
 	}
 
-	solved, err := sudoku.Solve()
+	solved, err := s.Solve()
 
 	if err != nil {
 		fmt.Println("NO SOLUTION FOUND")
-		os.Exit(1)
+		printConflict(s)
+		return 1
+	}
+
+	fmt.Print(solved.String())
+	return 0
+}
+
+// printConflict prints the clues of s that have no solution together, if
+// there are any.
+func printConflict(s sudoku.Sudoku) {
+	clues, err := s.ConflictingClues()
+	if err != nil || len(clues) == 0 {
 		return
 	}
+	fields := make([]string, len(clues))
+	for i, c := range clues {
+		fields[i] = c.String()
+	}
+	fmt.Println("Conflicting clues:", strings.Join(fields, " "))
+}
+
+func solveSamurai() int {
+	samurai, err := sudoku.ParseSamuraiReader(bufio.NewReader(os.Stdin))
+	if err != nil {
+		fmt.Println(err)
+		return 0
+	}
+
+	solved, err := samurai.Solve()
+	if err != nil {
+		fmt.Println("NO SOLUTION FOUND")
+		return 1
+	}
 
 	fmt.Print(solved.String())
+	return 0
+}
+
+func explain(args []string) int {
+	fs := flag.NewFlagSet("explain", flag.ExitOnError)
+	opts := parseOptions(fs)
+	fs.Parse(args)
+
+	s, err := opts.ParseReader(bufio.NewReader(os.Stdin))
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+
+	steps, err := s.Explain()
+	for i, step := range steps {
+		fmt.Printf("%3d. %v\n", i+1, step)
+	}
+
+	switch err {
+	case nil:
+		solved, _, _ := s.SolveLogically()
+		fmt.Print("\n", solved.String())
+		return 0
+	case sudoku.ErrStuck:
+		partial, _, _ := s.SolveLogically()
+		fmt.Print("\nNo technique applies anymore, guessing is required from here:\n\n", partial.String())
+		return 1
+	default:
+		fmt.Println("NO SOLUTION FOUND")
+		printConflict(s)
+		return 1
+	}
+}
+
+func rate(args []string) int {
+	fs := flag.NewFlagSet("rate", flag.ExitOnError)
+	opts := parseOptions(fs)
+	fs.Parse(args)
+
+	rr := bufio.NewReader(os.Stdin)
+	exit := 0
+	for n := 1; ; n++ {
+		s, err := opts.ParseReader(rr)
+		if err == io.EOF {
+			return exit
+		}
+		if err != nil {
+			// the rest of the input can't be lined up with the squares
+			// anymore, so there is no point in reading on
+			fmt.Printf("%d: %v (counting lines from the start of this sudoku)\n", n, err)
+			return 1
+		}
+
+		rating, err := s.Rate()
+		if err != nil {
+			fmt.Printf("%d: NO SOLUTION FOUND\n", n)
+			exit = 1
+			continue
+		}
+		fmt.Printf("%d: %v\n", n, rating)
+	}
+}
+
+func generate(args []string) int {
+	fs := flag.NewFlagSet("generate", flag.ExitOnError)
+	tierName := fs.String("tier", "medium", "difficulty: easy, medium, hard, expert or extreme")
+	symmetryName := fs.String("symmetry", "none", "arrangement of clues: none, rotational, diagonal or mirror")
+	seed := fs.Int64("seed", time.Now().UnixNano(), "seed for reproducible results")
+	fs.Parse(args)
+
+	opts := sudoku.GenerateOptions{Seed: *seed}
+	for opts.Tier = sudoku.Easy; !strings.EqualFold(opts.Tier.String(), *tierName); opts.Tier++ {
+		if opts.Tier > sudoku.Extreme {
+			fmt.Fprintln(os.Stderr, "unknown tier", *tierName)
+			return 2
+		}
+	}
+	var ok bool
+	if opts.Symmetry, ok = parseSymmetry(*symmetryName); !ok {
+		fmt.Fprintln(os.Stderr, "unknown symmetry", *symmetryName)
+		return 2
+	}
+
+	s, err := sudoku.Generate(opts)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	rating, _ := s.Rate()
+
+	fmt.Fprintf(os.Stderr, "seed %d, rated %v\n", *seed, rating)
+	fmt.Print(s.String())
+	return 0
+}
+
+func parseSymmetry(name string) (sudoku.Symmetry, bool) {
+	for sym := sudoku.NoSymmetry; sym <= sudoku.Mirror; sym++ {
+		if strings.EqualFold(sym.String(), name) {
+			return sym, true
+		}
+	}
+	return sudoku.NoSymmetry, false
+}
+
+func minimize(args []string) int {
+	fs := flag.NewFlagSet("minimize", flag.ExitOnError)
+	symmetryName := fs.String("symmetry", "none", "symmetry to preserve: none, rotational, diagonal or mirror")
+	shuffle := fs.Bool("shuffle", false, "remove clues in random order instead of from A1 to I9")
+	seed := fs.Int64("seed", 0, "seed for the random order")
+	parseOpts := parseOptions(fs)
+	fs.Parse(args)
+
+	opts := sudoku.MinimizeOptions{Shuffle: *shuffle, Seed: *seed}
+	var ok bool
+	if opts.Symmetry, ok = parseSymmetry(*symmetryName); !ok {
+		fmt.Fprintln(os.Stderr, "unknown symmetry", *symmetryName)
+		return 2
+	}
+
+	s, err := parseOpts.ParseReader(bufio.NewReader(os.Stdin))
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+
+	minimal, err := s.Minimize(opts)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	fmt.Print(minimal.String())
+	return 0
 }
@@ -0,0 +1,71 @@
+package sudoku
+
+import (
+	"context"
+)
+
+// Conflict diagnosis
+
+// ConflictingClues explains why the receiver has no solution. It returns a
+// minimal set of its clues that have no solution on their own: removing any
+// one of them leaves clues that can be solved. There can be several such
+// sets, and the one returned isn't necessarily the smallest. The clues are
+// given in order, from A1 to I9.
+//
+// If the clues of the receiver have a solution, nil is returned. If the rules
+// alone have no solution, not even without any clues, the error is
+// ErrConflict.
+func (s Sudoku) ConflictingClues() ([]Candidate, error) {
+	return s.ConflictingCluesContext(context.Background())
+}
+
+// ConflictingCluesContext is like ConflictingClues, but gives up as soon as
+// ctx is done, returning the error of ctx.Err().
+func (s Sudoku) ConflictingCluesContext(ctx context.Context) ([]Candidate, error) {
+	blank, err := s.blank()
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := blank.solve(ctx); err != nil {
+		return nil, err
+	}
+
+	conflicting := func(clues []uint8) (bool, error) {
+		s, err := withClues(blank, clues)
+		if err == ErrConflict {
+			return true, nil
+		}
+		if _, _, err = s.solve(ctx); err == ErrConflict {
+			return true, nil
+		}
+		return false, err
+	}
+
+	clues := append([]uint8(nil), s.clues...)
+	if ok, err := conflicting(clues); !ok || err != nil {
+		return nil, err
+	}
+	// every clue that isn't needed for the conflict is dropped, the ones
+	// left are needed even without the clues dropped later on
+	for c, sv := range clues {
+		if sv == 0 {
+			continue
+		}
+		clues[c] = 0
+		ok, err := conflicting(clues)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			clues[c] = sv
+		}
+	}
+
+	var res []Candidate
+	for c, sv := range clues {
+		if sv != 0 {
+			res = append(res, s.shape().candidate(coordinate(c), sv))
+		}
+	}
+	return res, nil
+}
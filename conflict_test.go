@@ -0,0 +1,76 @@
+package sudoku
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestConflictingClues(t *testing.T) {
+	// 9 can't go into A7 to A9 in row A because of B9, whereas E5 is fine
+	s, err := Parse("12345678." + "........9" + strings.Repeat(".", 22) + "9" + strings.Repeat(".", 40))
+	if !errors.Is(err, ErrConflict) {
+		t.Fatal("Expected a conflict, but got", err)
+	}
+	clues, err := s.ConflictingClues()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, c := range clues {
+		names = append(names, c.String())
+	}
+	expected := "A1=1 A2=2 A3=3 A4=4 A5=5 A6=6 B9=9"
+	if actual := strings.Join(names, " "); actual != expected {
+		t.Error("Expected", expected, "but got", actual)
+	}
+
+	s, _ = Parse("5.5" + strings.Repeat(".", 78))
+	if clues, _ := s.ConflictingClues(); len(clues) != 2 || clues[0].String() != "A1=5" || clues[1].String() != "A3=5" {
+		t.Error("Expected both 5s to conflict, but got", clues)
+	}
+
+	s, _ = Parse(thermoSudoku)
+	if clues, err := s.ConflictingClues(); clues != nil || err != nil {
+		t.Error("Expected no conflict, but got", clues, err)
+	}
+}
+
+func TestConflictingCluesMinimal(t *testing.T) {
+	// the puzzle of TestCountSolutions, with a wrong value in I9 that passes
+	// all checks while parsing
+	s, err := Parse("85...24..72......9..4.........1.7..23.5...9...4...........8..7..17..........36.45")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Solve(); err != ErrConflict {
+		t.Fatal("Expected no solution, but got", err)
+	}
+
+	clues, err := s.ConflictingClues()
+	if err != nil {
+		t.Fatal(err)
+	}
+	core := Sudoku{}
+	for _, c := range clues {
+		if core, err = core.WithCellValued(rune(c.Cell[0]), rune(c.Cell[1]), c.Value); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		if _, err = core.Solve(); err != ErrConflict {
+			t.Error("Expected the clues", clues, "to have no solution")
+		}
+	}
+	for i := range clues {
+		without := Sudoku{}
+		for j, c := range clues {
+			if i != j {
+				without, _ = without.WithCellValued(rune(c.Cell[0]), rune(c.Cell[1]), c.Value)
+			}
+		}
+		if _, err := without.Solve(); err != nil {
+			t.Error("Expected a solution without", clues[i], "but got", err)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package sudoku
+
+import (
+	"fmt"
+)
+
+// Constraints
+
+// A Constraint is a rule that the solution of a sudoku has to follow. The
+// classic rules consist of three constraints, one value in every row, column
+// and box. Variants are expressed by replacing or adding constraints, see
+// ParseOptions.
+//
+// The first three constraints of a sudoku always need to be Rows, Columns and
+// a division of the board into boxes, like Boxes does. Constraints can only
+// be created by the functions of this package.
+type Constraint interface {
+	// units returns the groups of squares that have to contain every value
+	// exactly once.
+	units(l *layout) [][]coordinate
+	// peers returns the squares that can't take the same value as c, in
+	// addition to the ones sharing a unit with it.
+	peers(l *layout, c coordinate) []coordinate
+	// restrict is called once for the empty board, and eliminates whatever
+	// the constraint rules out from the start.
+	restrict(p propagator) error
+	// propagate is called whenever the square at c is filled in with sv, and
+	// eliminates whatever the constraint rules out because of that. It
+	// returns an error if the constraint can't be satisfied anymore.
+	propagate(p propagator, c coordinate, sv uint8) error
+}
+
+// The constraints of a standard sudoku.
+var (
+	// Rows contain every value once.
+	Rows Constraint = lines{byColumn: false}
+	// Columns contain every value once.
+	Columns Constraint = lines{byColumn: true}
+	// Boxes contain every value once.
+	Boxes Constraint = boxes{}
+)
+
+// Classic returns the rules of a standard sudoku, which are used unless
+// other constraints are given.
+func Classic() []Constraint {
+	return []Constraint{Rows, Columns, Boxes}
+}
+
+// A unitNamer is a constraint that names its units, for explanations.
+// Units of other constraints are just numbered.
+type unitNamer interface {
+	unitName(l *layout, i int) string
+}
+
+// A marker is a constraint that marks squares in the output of String. The
+// mark is printed right after the value of the square.
+type marker interface {
+	mark(l *layout, c coordinate) (byte, bool)
+}
+
+// An appender is a constraint that describes itself below the grid in the
+// output of String.
+type appender interface {
+	appendix(l *layout) string
+}
+
+// A framer is a constraint that shows clues around the grid in the output of
+// String, above every column and beside every row. Empty strings stand for
+// missing clues.
+type framer interface {
+	frame(l *layout) (columns, rows []string)
+}
+
+// unitConstraint provides the defaults for constraints that are made of units
+// alone.
+type unitConstraint struct{}
+
+func (unitConstraint) peers(l *layout, c coordinate) []coordinate {
+	return nil
+}
+
+func (unitConstraint) restrict(p propagator) error {
+	return nil
+}
+
+func (unitConstraint) propagate(p propagator, c coordinate, sv uint8) error {
+	return nil
+}
+
+type lines struct {
+	unitConstraint
+	byColumn bool
+}
+
+func (ls lines) units(l *layout) [][]coordinate {
+	res := make([][]coordinate, l.size)
+	for i := range res {
+		res[i] = make([]coordinate, l.size)
+		for j := range res[i] {
+			if ls.byColumn {
+				res[i][j] = l.coord(j, i)
+			} else {
+				res[i][j] = l.coord(i, j)
+			}
+		}
+	}
+	return res
+}
+
+func (ls lines) unitName(l *layout, i int) string {
+	if ls.byColumn {
+		return fmt.Sprintf("column %d", i+1)
+	}
+	return fmt.Sprintf("row %c", 'A'+i)
+}
+
+type boxes struct {
+	unitConstraint
+}
+
+func (boxes) units(l *layout) [][]coordinate {
+	res := make([][]coordinate, l.size)
+	for b := range res {
+		for i := 0; i < l.size; i++ {
+			row := (b/l.box)*l.box + i/l.box
+			col := (b%l.box)*l.box + i%l.box
+			res[b] = append(res[b], l.coord(row, col))
+		}
+	}
+	return res
+}
+
+func (boxes) unitName(l *layout, i int) string {
+	return fmt.Sprintf("box %d", i+1)
+}
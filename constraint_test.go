@@ -0,0 +1,124 @@
+package sudoku
+
+import (
+	"strings"
+	"testing"
+)
+
+// differentCorners is a test constraint that makes A1 and I9 peers.
+type differentCorners struct {
+	unitConstraint
+}
+
+func (differentCorners) units(l *layout) [][]coordinate {
+	return nil
+}
+
+func (differentCorners) peers(l *layout, c coordinate) []coordinate {
+	last := coordinate(l.numCells - 1)
+	switch c {
+	case 0:
+		return []coordinate{last}
+	case last:
+		return []coordinate{0}
+	}
+	return nil
+}
+
+// oddCorner is a test constraint that only allows odd values in A1, using
+// propagation.
+type oddCorner struct {
+	unitConstraint
+}
+
+func (oddCorner) units(l *layout) [][]coordinate {
+	return nil
+}
+
+func (oddCorner) propagate(p propagator, c coordinate, sv uint8) error {
+	if c == 0 && sv%2 == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+func TestClassicConstraints(t *testing.T) {
+	l, err := newLayout(3, Classic())
+	if err != nil {
+		t.Fatal(err)
+	}
+	peers := l.peers[coord('E', '5')]
+	if len(peers) != 20 {
+		t.Fatal("Expected 20 peers, but got", len(peers))
+	}
+	// column first, then the row, then the rest of the box
+	if peers[0] != coord('A', '5') || peers[8] != coord('E', '1') || peers[16] != coord('D', '4') {
+		t.Error("Unexpected order of peers", peers)
+	}
+	if len(l.units) != 27 || l.unitSearchOrder[0] != 18 {
+		t.Error("Unexpected units", l.units)
+	}
+}
+
+func TestInvalidConstraints(t *testing.T) {
+	cases := [][]Constraint{
+		{},
+		{Rows, Columns},
+		{Columns, Rows, Boxes},
+		{Rows, Columns, oddCorner{}},
+		{Rows, Rows, Boxes},
+	}
+	for _, constraints := range cases {
+		if _, err := (ParseOptions{Constraints: constraints}).Parse(""); err != ErrInvalidConstraints {
+			t.Error("Expected ErrInvalidConstraints for", constraints, "but got", err)
+		}
+	}
+}
+
+func TestConstraintPeers(t *testing.T) {
+	opts := ParseOptions{Constraints: append(Classic(), differentCorners{})}
+	s, err := opts.Parse("1" + strings.Repeat(".", 80))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.square(coord('I', '9')).(emptySquare).isValuePossible(1) {
+		t.Error("Expected 1 to be eliminated from I9")
+	}
+
+	solved, err := s.Solve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertIsSolved(solved, t)
+	if v := solved.value(coord('I', '9')); v == 1 {
+		t.Error("Expected I9 to differ from A1, but got\n", solved)
+	}
+}
+
+func TestConstraintPropagate(t *testing.T) {
+	plain, err := (Sudoku{}).Solve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain.value(0)%2 != 0 {
+		t.Fatal("Expected an even value in A1 without the constraint, but got\n", plain)
+	}
+
+	opts := ParseOptions{Constraints: append(Classic(), oddCorner{})}
+	s, err := opts.Parse(strings.Repeat(".", 81))
+	if err != nil {
+		t.Fatal(err)
+	}
+	solved, err := s.Solve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertIsSolved(solved, t)
+	if v := solved.value(0); v%2 == 0 {
+		t.Error("Expected an odd value in A1, but got\n", solved)
+	}
+
+	if _, err := s.WithCellValued('A', '1', 4); err != ErrConflict {
+		t.Error("Expected ErrConflict, but got", err)
+	}
+}
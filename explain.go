@@ -0,0 +1,185 @@
+package sudoku
+
+import (
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrStuck is returned when none of the techniques makes progress, so
+	// that the sudoku can't be solved without guessing.
+	ErrStuck = fmt.Errorf("Stuck")
+)
+
+// A Step explains a single deduction made while solving logically.
+type Step struct {
+	Technique Technique
+	// Units names the rows, columns and boxes the pattern was found in, for
+	// example "row A", "column 5" or "box 9".
+	Units []string
+	// Cells are the squares forming the pattern, in A1..I9 notation. Larger
+	// sudokus continue with further letters and numbers, as in P16.
+	Cells []string
+	// Values are the values forming the pattern.
+	Values []uint8
+	// Placements are the values placed by this step.
+	Placements []Candidate
+	// Eliminations are the candidates removed by this step.
+	Eliminations []Candidate
+
+	// digits are the runes standing for the values on the board, see
+	// layout.digits. If empty, values are written as numbers.
+	digits string
+}
+
+// A Candidate is a value for a square given in A1..I9 notation, see
+// Step.Cells.
+type Candidate struct {
+	Cell  string
+	Value uint8
+
+	// digit is the rune standing for Value on the board, zero meaning that
+	// the value is written as a number.
+	digit rune
+}
+
+// candidate returns the candidate for the value sv in the square at c.
+func (l *layout) candidate(c coordinate, sv uint8) Candidate {
+	return Candidate{l.name(c), sv, rune(l.digits[sv])}
+}
+
+// String returns the candidate as in E6=8, writing the value as it appears
+// on the board, for example E6=G on 16x16 boards.
+func (c Candidate) String() string {
+	return c.Cell + "=" + c.symbol()
+}
+
+func (c Candidate) symbol() string {
+	if c.digit == 0 {
+		return fmt.Sprint(c.Value)
+	}
+	return string(c.digit)
+}
+
+// Explain solves the clues of the receiver like SolveLogically, and returns
+// every step taken, in order. If the techniques are not sufficient to solve
+// the sudoku, the steps up to that point are returned together with
+// ErrStuck.
+func (s Sudoku) Explain() ([]Step, error) {
+	g, deductions, err := s.deduceLogically()
+
+	steps := make([]Step, len(deductions))
+	for i, d := range deductions {
+		steps[i] = d.step(s.shape())
+	}
+	if err != nil {
+		return steps, err
+	}
+	if !g.isFilledOut() {
+		return steps, ErrStuck
+	}
+	return steps, nil
+}
+
+func (d deduction) step(l *layout) Step {
+	st := Step{Technique: d.technique, Values: d.values, digits: l.digits}
+	for _, u := range d.units {
+		st.Units = append(st.Units, l.unitName(u))
+	}
+	for _, c := range d.cells {
+		st.Cells = append(st.Cells, l.name(c))
+	}
+	for _, p := range d.placements {
+		st.Placements = append(st.Placements, l.candidate(p.c, p.sv))
+	}
+	for _, e := range d.eliminations {
+		st.Eliminations = append(st.Eliminations, l.candidate(e.c, e.sv))
+	}
+	return st
+}
+
+// String explains the step in a single English sentence. Values are written
+// as they appear on the board, for example G on 16x16 boards.
+func (st Step) String() string {
+	value := func(sv uint8) string {
+		return Candidate{Value: sv, digit: st.digit(sv)}.symbol()
+	}
+	values := func() string {
+		elems := make([]string, len(st.Values))
+		for i, sv := range st.Values {
+			elems[i] = value(sv)
+		}
+		return joinList(elems)
+	}
+
+	var reason string
+	switch st.Technique {
+	case HiddenSingle:
+		return fmt.Sprintf("%v: %s can only go into %s in %s.", st.Technique, value(st.Values[0]), st.Cells[0], st.Units[0])
+	case NakedSingle:
+		return fmt.Sprintf("%v: %s can only take %s.", st.Technique, st.Cells[0], value(st.Values[0]))
+	case PointingPair, BoxLineReduction:
+		reason = fmt.Sprintf("in %s, %s can only go into %s, which are all in %s",
+			st.Units[0], value(st.Values[0]), joinList(st.Cells), st.Units[1])
+	case NakedPair, NakedTriple, NakedQuad:
+		reason = fmt.Sprintf("in %s, %s can only take %s", st.Units[0], joinList(st.Cells), values())
+	case HiddenPair, HiddenTriple, HiddenQuad:
+		reason = fmt.Sprintf("in %s, %s can only go into %s", st.Units[0], values(), joinList(st.Cells))
+	case XWing, Swordfish, Jellyfish:
+		reason = fmt.Sprintf("in %s, %s can only go into %s", joinList(st.Units), value(st.Values[0]), joinList(st.Cells))
+	case XYWing, XYZWing:
+		reason = fmt.Sprintf("pivot %s and pincers %s with %s", st.Cells[0], joinList(st.Cells[1:]), values())
+	case SimpleColouring:
+		reason = fmt.Sprintf("%s alternates along the chain %s", value(st.Values[0]), strings.Join(st.Cells, " - "))
+	default:
+		reason = joinList(st.Cells)
+	}
+	byCell := st.Technique == HiddenPair || st.Technique == HiddenTriple || st.Technique == HiddenQuad
+	return fmt.Sprintf("%v: %s, so %s.", st.Technique, reason, describeEliminations(st.Eliminations, byCell))
+}
+
+// digit returns the rune standing for sv, zero if the step doesn't know the
+// board.
+func (st Step) digit(sv uint8) rune {
+	if st.digits == "" {
+		return 0
+	}
+	return rune(st.digits[sv])
+}
+
+// describeEliminations groups the eliminations by value, e.g. "remove 3 from
+// A1 and A2; 5 from B7". If byCell is set, they are grouped by square
+// instead, e.g. "remove 3 and 5 from A1; 7 from B2".
+func describeEliminations(elims []Candidate, byCell bool) string {
+	var order []string
+	groups := make(map[string][]string)
+	for _, e := range elims {
+		key, elem := e.symbol(), e.Cell
+		if byCell {
+			key, elem = elem, key
+		}
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], elem)
+	}
+
+	parts := make([]string, len(order))
+	for i, key := range order {
+		if byCell {
+			parts[i] = joinList(groups[key]) + " from " + key
+		} else {
+			parts[i] = key + " from " + joinList(groups[key])
+		}
+	}
+	return "remove " + strings.Join(parts, "; ")
+}
+
+// joinList joins the elements with commas, except for the last two, which
+// are joined by "and".
+func joinList(elems []string) string {
+	if len(elems) < 2 {
+		return strings.Join(elems, "")
+	}
+	return strings.Join(elems[:len(elems)-1], ", ") + " and " + elems[len(elems)-1]
+}
@@ -0,0 +1,118 @@
+package sudoku
+
+import (
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	s, err := Parse("85...24..72......9..4.........1.7..23.5...9...4...........8..7..17..........36.4.")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps, err := s.Explain()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	placed := 0
+	for _, step := range steps {
+		placed += len(step.Placements)
+	}
+	if placed != 81-22 {
+		t.Error("Expected every empty square to be placed once, but got", placed)
+	}
+
+	first := steps[0]
+	if first.Technique != HiddenSingle || first.Cells[0] != "E2" || first.Units[0] != "box 4" {
+		t.Error("Unexpected first step", first)
+	}
+	if actual := first.String(); actual != "Hidden Single: 7 can only go into E2 in box 4." {
+		t.Error("Unexpected explanation", actual)
+	}
+}
+
+func TestExplainStuck(t *testing.T) {
+	s, err := Parse("..53.....8......2..7..1.5..4....53...1..7...6..32...8..6.5....9..4....3......97..")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps, err := s.Explain()
+	if err != ErrStuck {
+		t.Fatal("Expected to get stuck, but got", err)
+	}
+	if len(steps) == 0 {
+		t.Fatal("Expected some steps before getting stuck")
+	}
+}
+
+func TestStepString(t *testing.T) {
+	cases := []struct {
+		step     Step
+		expected string
+	}{
+		{
+			Step{Technique: NakedSingle, Cells: []string{"E6"}, Values: []uint8{8}, Placements: []Candidate{{Cell: "E6", Value: 8}}},
+			"Naked Single: E6 can only take 8.",
+		},
+		{
+			Step{
+				Technique:    PointingPair,
+				Units:        []string{"box 4", "row F"},
+				Cells:        []string{"F1", "F3"},
+				Values:       []uint8{2},
+				Eliminations: []Candidate{{Cell: "F4", Value: 2}, {Cell: "F5", Value: 2}},
+			},
+			"Pointing Pair: in box 4, 2 can only go into F1 and F3, which are all in row F, so remove 2 from F4 and F5.",
+		},
+		{
+			Step{
+				Technique:    HiddenPair,
+				Units:        []string{"box 4"},
+				Cells:        []string{"D3", "F1"},
+				Values:       []uint8{6, 7},
+				Eliminations: []Candidate{{Cell: "D3", Value: 2}, {Cell: "D3", Value: 8}, {Cell: "F1", Value: 5}},
+			},
+			"Hidden Pair: in box 4, 6 and 7 can only go into D3 and F1, so remove 2 and 8 from D3; 5 from F1.",
+		},
+		{
+			Step{
+				Technique:    XYWing,
+				Cells:        []string{"A1", "A5", "D1"},
+				Values:       []uint8{1, 2, 3},
+				Eliminations: []Candidate{{Cell: "D5", Value: 3}},
+			},
+			"XY-Wing: pivot A1 and pincers A5 and D1 with 1, 2 and 3, so remove 3 from D5.",
+		},
+	}
+
+	for _, c := range cases {
+		if actual := c.step.String(); actual != c.expected {
+			t.Errorf("Expected %q, but got %q", c.expected, actual)
+		}
+	}
+}
+
+func TestStepStringLargeBoards(t *testing.T) {
+	d := deduction{
+		technique:    PointingPair,
+		units:        []int{32, 0},
+		cells:        []coordinate{0, 1},
+		values:       []uint8{16},
+		eliminations: []candidate{{4, 16}, {5, 16}},
+	}
+	st := d.step(layouts[4])
+	expected := "Pointing Pair: in box 1, G can only go into A1 and A2, which are all in row A, so remove G from A5 and A6."
+	if actual := st.String(); actual != expected {
+		t.Errorf("Expected %q, but got %q", expected, actual)
+	}
+	if actual := st.Eliminations[0].String(); actual != "A5=G" {
+		t.Error("Expected A5=G, but got", actual)
+	}
+
+	d = deduction{technique: NakedSingle, cells: []coordinate{0}, values: []uint8{10}, placements: []candidate{{0, 10}}}
+	if actual := d.step(layouts[5]).String(); actual != "Naked Single: A1 can only take J." {
+		t.Error("Expected the letter J on 25x25 boards, but got", actual)
+	}
+}
@@ -3,14 +3,21 @@
 package sudoku
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"time"
 
 	"html/template"
 )
 
+// solveTimeout bounds the time spent on a single request, so that
+// pathological input can't keep an instance busy.
+const solveTimeout = 5 * time.Second
+
 func init() {
 	http.HandleFunc("/solve", jsonHandler)
 	http.HandleFunc("/", pageHandler)
@@ -33,20 +40,61 @@ func getSource(r *http.Request) (string, error) {
 	return string(bs), nil
 }
 
-func genericSolve(input string) (*Sudoku, error) {
+func genericSolve(ctx context.Context, input string) (*Sudoku, error) {
+	ctx, cancel := context.WithTimeout(ctx, solveTimeout)
+	defer cancel()
+
 	s, err := Parse(input)
+	if errors.Is(err, ErrConflict) {
+		return nil, diagnose(ctx, s, fmt.Errorf("%s", err.Error()))
+	}
 	if err != nil {
 		return nil, fmt.Errorf("%s", err.Error())
 	}
 
-	solved, err := s.Solve()
+	solved, err := s.SolveContext(ctx)
+	if err == context.DeadlineExceeded {
+		return nil, fmt.Errorf("Gave up, solving took too long")
+	}
 	if err != nil {
-		return nil, fmt.Errorf("No solution found")
+		return nil, diagnose(ctx, s, fmt.Errorf("No solution found"))
 	}
 
 	return &solved, nil
 }
 
+// conflictError adds the clues that have no solution together to err.
+type conflictError struct {
+	err       error
+	clues     [9][9]uint8
+	conflicts [9][9]bool
+	names     []string
+}
+
+func (e conflictError) Error() string {
+	return fmt.Sprintf("%v, the clues %s conflict", e.err, joinList(e.names))
+}
+
+// diagnose looks for the clues of s causing err. If they can't be found in
+// time, err is returned as it is.
+func diagnose(ctx context.Context, s Sudoku, err error) error {
+	clues, cerr := s.ConflictingCluesContext(ctx)
+	if cerr != nil || len(clues) == 0 || s.shape() != layouts[3] {
+		return err
+	}
+
+	res := conflictError{err: err}
+	for c, sv := range s.clues {
+		res.clues[c/9][c%9] = sv
+	}
+	for _, clue := range clues {
+		pos, _ := parsePosition(clue.Cell)
+		res.conflicts[pos.row][pos.col] = true
+		res.names = append(res.names, clue.String())
+	}
+	return res
+}
+
 func jsonHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "POST" {
 		w.Header().Add("Allow", "POST")
@@ -60,7 +108,7 @@ func jsonHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	solved, err := genericSolve(source)
+	solved, err := genericSolve(r.Context(), source)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -75,8 +123,11 @@ func jsonHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 type pageTemplateData struct {
-	Err       error
-	Cells     [9][9]uint8
+	Err   error
+	Cells [9][9]uint8
+	// Conflicts marks the clues that have no solution together, if Cells
+	// shows the clues instead of the solution.
+	Conflicts [9][9]bool
 	ShowCells bool
 	Source    string
 }
@@ -90,7 +141,11 @@ func pageHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		solved, err := genericSolve(source)
+		solved, err := genericSolve(r.Context(), source)
+		if ce, ok := err.(conflictError); ok {
+			pageTemplate.Execute(w, pageTemplateData{Err: err, Source: source, ShowCells: true, Cells: ce.clues, Conflicts: ce.conflicts})
+			return
+		}
 		if err != nil {
 			pageTemplate.Execute(w, pageTemplateData{Err: err, Source: source, ShowCells: true})
 			return
@@ -139,16 +194,16 @@ var pageTemplate = template.Must(template.New("page").Parse(`
 				</div>
 				<div class="col-md-6">
 					{{if .ShowCells}}
-						<div class="panel panel-success">
+						<div class="panel {{if .Err}}panel-danger{{else}}panel-success{{end}}">
 							<div class="panel-heading">
-								<h2 class="panel-title">Solution</h2>
+								<h2 class="panel-title">{{if .Err}}Clues{{else}}Solution{{end}}</h2>
 							</div>
 							<div class="panel-body">
 								<table class="table">
-								{{range .Cells}}
+								{{range $row, $cells := .Cells}}
 									<tr>
-										{{range .}}
-											<td>{{.}}</td>
+										{{range $col, $value := $cells}}
+											<td{{if index $.Conflicts $row $col}} class="danger"{{end}}>{{$value}}</td>
 										{{end}}
 									</tr>
 								{{end}}
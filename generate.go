@@ -0,0 +1,190 @@
+package sudoku
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Generating
+
+var (
+	// ErrGenerationFailed is returned if no sudoku matching the requested
+	// options could be found within a reasonable number of attempts.
+	ErrGenerationFailed = fmt.Errorf("Unable to generate sudoku")
+)
+
+// A Symmetry describes how the clues of a sudoku are arranged.
+type Symmetry uint8
+
+const (
+	// NoSymmetry places clues anywhere.
+	NoSymmetry Symmetry = iota
+	// Rotational keeps the clues the same when rotating by 180 degrees.
+	Rotational
+	// Diagonal keeps the clues the same when mirroring along the diagonal from
+	// A1 to I9.
+	Diagonal
+	// Mirror keeps the clues the same when mirroring left to right.
+	Mirror
+)
+
+var symmetryNames = [...]string{
+	NoSymmetry: "None",
+	Rotational: "Rotational",
+	Diagonal:   "Diagonal",
+	Mirror:     "Mirror",
+}
+
+func (sym Symmetry) String() string {
+	if int(sym) < len(symmetryNames) {
+		return symmetryNames[sym]
+	}
+	return fmt.Sprintf("Symmetry(%d)", uint8(sym))
+}
+
+// groups returns the squares grouped such that each group is mapped onto
+// itself by the symmetry. Clues are always added or removed a whole group at
+// a time.
+func (sym Symmetry) groups(l *layout) [][]coordinate {
+	var res [][]coordinate
+	seen := make(map[coordinate]bool)
+	for c := coordinate(0); int(c) < l.numCells; c++ {
+		if seen[c] {
+			continue
+		}
+
+		size := coordinate(l.size)
+		r, col := c/size, c%size
+		group := []coordinate{c}
+		var other coordinate
+		switch sym {
+		case Rotational:
+			other = coordinate(l.numCells) - 1 - c
+		case Diagonal:
+			other = col*size + r
+		case Mirror:
+			other = r*size + size - 1 - col
+		default:
+			other = c
+		}
+		if other != c {
+			group = append(group, other)
+		}
+
+		for _, x := range group {
+			seen[x] = true
+		}
+		res = append(res, group)
+	}
+	return res
+}
+
+// GenerateOptions describes the sudoku to generate.
+type GenerateOptions struct {
+	// Tier is the requested difficulty, see Rate.
+	Tier Tier
+	// Symmetry is the arrangement of the clues.
+	Symmetry Symmetry
+	// Seed initializes the random numbers. Generating with the same options
+	// always results in the same sudoku.
+	Seed int64
+}
+
+// generateAttempts is the number of solutions tried before giving up.
+const generateAttempts = 100
+
+// Generate creates a new standard sudoku with a unique solution. Starting from a random
+// solution, clues are removed in random order, keeping only removals that
+// neither make the solution ambiguous nor the sudoku harder than requested.
+// If the result is easier than requested, it starts over.
+//
+// The returned sudoku contains only the clues, nothing is filled in by
+// propagation.
+func Generate(opts GenerateOptions) (Sudoku, error) {
+	rnd := rand.New(rand.NewSource(opts.Seed))
+	l := layouts[3]
+	blank := Sudoku{layout: l}.clone()
+
+	for attempt := 0; attempt < generateAttempts; attempt++ {
+		groups := opts.Symmetry.groups(l)
+		rnd.Shuffle(len(groups), func(i, j int) {
+			groups[i], groups[j] = groups[j], groups[i]
+		})
+
+		clues := removeClues(blank, randomSolution(l, rnd), groups, func(s Sudoku) bool {
+			if !s.IsUnique() {
+				return false
+			}
+			if opts.Tier == Extreme {
+				return true
+			}
+			rating, err := s.Rate()
+			return err == nil && rating.Tier <= opts.Tier
+		})
+
+		s, err := withClues(blank, clues)
+		if err != nil {
+			continue
+		}
+		if rating, err := s.Rate(); err == nil && rating.Tier == opts.Tier {
+			return s.cluesOnly()
+		}
+	}
+	return Sudoku{}, ErrGenerationFailed
+}
+
+// randomSolution returns the values of a randomly chosen solved sudoku.
+func randomSolution(l *layout, rnd *rand.Rand) []uint8 {
+	values := make([]uint8, l.numCells)
+	se := searcher{
+		rnd: rnd,
+		found: func(solved Sudoku) bool {
+			for c := range values {
+				values[c] = solved.value(coordinate(c))
+			}
+			return false
+		},
+	}
+	se.search(Sudoku{layout: l}, 0)
+	return values
+}
+
+// removeClues removes the groups of clues in the given order, keeping every
+// removal that leads to a sudoku accepted by keep. The clues are filled into
+// blank, see withClues.
+func removeClues(blank Sudoku, clues []uint8, groups [][]coordinate, keep func(Sudoku) bool) []uint8 {
+	clues = append([]uint8(nil), clues...)
+	for _, group := range groups {
+		reduced := append([]uint8(nil), clues...)
+		changed := false
+		for _, c := range group {
+			changed = changed || reduced[c] != 0
+			reduced[c] = 0
+		}
+		if !changed {
+			continue
+		}
+
+		if s, err := withClues(blank, reduced); err == nil && keep(s) {
+			clues = reduced
+		}
+	}
+	return clues
+}
+
+// withClues returns blank with the given clues assigned, zero meaning that
+// there is no clue for that square. Blank is a sudoku without values, as
+// returned by blank.
+func withClues(blank Sudoku, clues []uint8) (Sudoku, error) {
+	s := blank
+	var err error
+	for c, sv := range clues {
+		if sv == 0 {
+			continue
+		}
+		if s, err = s.withClue(coordinate(c), sv); err != nil {
+			return s, err
+		}
+	}
+	return s, nil
+}
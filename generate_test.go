@@ -0,0 +1,85 @@
+package sudoku
+
+import (
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	for tier := Easy; tier <= Extreme; tier++ {
+		for sym := NoSymmetry; sym <= Mirror; sym++ {
+			s, err := Generate(GenerateOptions{Tier: tier, Symmetry: sym, Seed: 42})
+			if err != nil {
+				t.Error(tier, sym, err)
+				continue
+			}
+			if !s.IsUnique() {
+				t.Error(tier, sym, "is not unique")
+			}
+			if rating, err := s.Rate(); err != nil || rating.Tier != tier {
+				t.Error(tier, sym, "was rated as", rating, err)
+			}
+			assertHasSymmetry(t, s, sym)
+		}
+	}
+}
+
+func assertHasSymmetry(t *testing.T, s Sudoku, sym Symmetry) {
+	for _, group := range sym.groups(s.shape()) {
+		for _, c := range group {
+			if (s.clues[c] == 0) != (s.clues[group[0]] == 0) {
+				t.Error("Expected", sym, "symmetry for clues, but got\n", s)
+				return
+			}
+		}
+	}
+}
+
+func TestGenerateOnlyContainsClues(t *testing.T) {
+	s, err := Generate(GenerateOptions{Tier: Easy})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for c := range s.cells {
+		if s.value(coordinate(c)) != s.clues[c] {
+			t.Fatal("Expected only clues to be filled in, but got\n", s)
+		}
+	}
+}
+
+func TestGenerateIsReproducible(t *testing.T) {
+	opts := GenerateOptions{Tier: Hard, Symmetry: Rotational, Seed: 7}
+	a, err := Generate(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Generate(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.String() != b.String() {
+		t.Error("Expected the same sudoku for the same seed, but got\n", a, "and\n", b)
+	}
+
+	opts.Seed++
+	c, err := Generate(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.String() == c.String() {
+		t.Error("Expected different sudokus for different seeds")
+	}
+}
+
+func TestSymmetryGroups(t *testing.T) {
+	cases := map[Symmetry]int{
+		NoSymmetry: 81,
+		Rotational: 41,
+		Diagonal:   45,
+		Mirror:     45,
+	}
+	for sym, expected := range cases {
+		if n := len(sym.groups(layouts[3])); n != expected {
+			t.Error("Expected", expected, "groups for", sym, "but got", n)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package sudoku
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Jigsaw sudokus
+
+var (
+	// ErrInvalidRegions is returned by Jigsaw if the regions don't divide
+	// the board into connected regions of equal size.
+	ErrInvalidRegions = fmt.Errorf("Invalid regions")
+)
+
+// Jigsaw returns a constraint that replaces the boxes by irregular regions,
+// which have to contain every value once, just like the boxes. It has to be
+// the third constraint, see Constraint. The regions are given as a grid with
+// a label for every square, for example
+//
+//	AAABBBBCC
+//	AAABBBCCC
+//	...
+//
+// Whitespace is ignored, any other rune labels a region. There have to be as
+// many regions as rows, each with as many squares, and the squares of a
+// region have to be connected horizontally or vertically. Otherwise
+// ErrInvalidRegions is returned.
+//
+// The regions are shown by String, using '|' and '-' between squares of
+// different regions.
+func Jigsaw(regions string) (Constraint, error) {
+	var labels []rune
+	for _, r := range regions {
+		if !unicode.IsSpace(r) {
+			labels = append(labels, r)
+		}
+	}
+
+	size := 0
+	for box := 2; box < len(layouts); box++ {
+		if box*box*box*box == len(labels) {
+			size = box * box
+		}
+	}
+	if size == 0 {
+		return nil, ErrInvalidRegions
+	}
+
+	j := jigsaw{size: size}
+	index := make(map[rune]int)
+	for c, label := range labels {
+		i, ok := index[label]
+		if !ok {
+			i = len(j.regions)
+			index[label] = i
+			j.regions = append(j.regions, nil)
+			j.labels = append(j.labels, label)
+		}
+		j.regions[i] = append(j.regions[i], coordinate(c))
+	}
+	if len(j.regions) != size {
+		return nil, ErrInvalidRegions
+	}
+	for _, region := range j.regions {
+		if len(region) != size || !connected(region, labels, size) {
+			return nil, ErrInvalidRegions
+		}
+	}
+	return j, nil
+}
+
+// connected reports whether all squares of the region can be reached from
+// its first one, going horizontally or vertically between squares with the
+// same label.
+func connected(region []coordinate, labels []rune, size int) bool {
+	label := labels[region[0]]
+	seen := map[coordinate]bool{region[0]: true}
+	todo := []coordinate{region[0]}
+	for len(todo) > 0 {
+		c := todo[len(todo)-1]
+		todo = todo[:len(todo)-1]
+
+		row, col := int(c)/size, int(c)%size
+		for _, n := range [][2]int{{row - 1, col}, {row + 1, col}, {row, col - 1}, {row, col + 1}} {
+			if n[0] < 0 || n[0] >= size || n[1] < 0 || n[1] >= size {
+				continue
+			}
+			next := coordinate(n[0]*size + n[1])
+			if labels[next] == label && !seen[next] {
+				seen[next] = true
+				todo = append(todo, next)
+			}
+		}
+	}
+	return len(seen) == len(region)
+}
+
+type jigsaw struct {
+	unitConstraint
+	size    int
+	regions [][]coordinate
+	labels  []rune
+}
+
+func (j jigsaw) units(l *layout) [][]coordinate {
+	if l.size != j.size {
+		return nil
+	}
+	return j.regions
+}
+
+func (j jigsaw) unitName(l *layout, i int) string {
+	return fmt.Sprintf("region %c", j.labels[i])
+}
+
+// ExtraRegions returns a constraint for regions that have to contain every
+// value once, in addition to the rows, columns and boxes. Windoku is an
+// example, see Windows. The regions are given as a grid with a label for
+// every square, like for Jigsaw, but only letters and digits are labels. A
+// dot marks a square outside of the extra regions, all other runes are
+// ignored. Every region needs as many squares as a row, otherwise
+// ErrInvalidRegions is returned.
+//
+// When the sudoku is printed, the regions are added below the grid in the
+// same format.
+func ExtraRegions(regions string) (Constraint, error) {
+	var labels []rune
+	for _, r := range regions {
+		if r == '.' || strings.ContainsRune(cageLabels, r) {
+			labels = append(labels, r)
+		}
+	}
+
+	size := 0
+	for box := 2; box < len(layouts); box++ {
+		if box*box*box*box == len(labels) {
+			size = box * box
+		}
+	}
+	if size == 0 {
+		return nil, ErrInvalidRegions
+	}
+
+	e := extraRegions{jigsaw{size: size}, labels}
+	index := make(map[rune]int)
+	for c, label := range labels {
+		if label == '.' {
+			continue
+		}
+		i, ok := index[label]
+		if !ok {
+			i = len(e.regions)
+			index[label] = i
+			e.regions = append(e.regions, nil)
+			e.labels = append(e.labels, label)
+		}
+		e.regions[i] = append(e.regions[i], coordinate(c))
+	}
+	for _, region := range e.regions {
+		if len(region) != size {
+			return nil, ErrInvalidRegions
+		}
+	}
+	return e, nil
+}
+
+type extraRegions struct {
+	jigsaw
+	grid []rune
+}
+
+// units returns the regions even if they don't fit the board, so that the
+// layout reports them as invalid.
+func (e extraRegions) units(l *layout) [][]coordinate {
+	return e.regions
+}
+
+func (e extraRegions) unitName(l *layout, i int) string {
+	return fmt.Sprintf("extra region %c", e.labels[i])
+}
+
+// appendix describes the regions in the format read by ExtraRegions.
+func (e extraRegions) appendix(l *layout) string {
+	return l.grid(func(c coordinate) string {
+		return string(e.grid[c])
+	}, false)
+}
@@ -0,0 +1,179 @@
+package sudoku
+
+import (
+	"strings"
+	"testing"
+)
+
+const jigsawRegions = `AABBBBCCC
+AAABBBCCC
+ADAAEBFCC
+ADDEEBFFC
+DDDEEEFFF
+DDGEEEFIF
+DGGGHHFII
+GGGGHHIII
+GHHHHHIII`
+
+const jigsawSudoku = "6.......2..3..............9......3..8..9.6...51......4..6......3.8.9.7.5...2.7..."
+
+func TestJigsaw(t *testing.T) {
+	opts := ParseOptions{Regions: jigsawRegions}
+	s, err := opts.Parse(jigsawSudoku)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.IsUnique() {
+		t.Error("Expected a unique solution")
+	}
+	if classic, _ := Parse(jigsawSudoku); classic.IsUnique() {
+		t.Error("Expected several solutions with the standard boxes")
+	}
+
+	solved, err := s.Solve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertIsSolved(solved, t)
+
+	expected := `6 9|4 7 1 8|5 3 2
+    -
+7 5 3|6 2 9|4 1 8
+  -   ---   -
+4|6|1 8|5|3|2|7 9
+    ---       -
+2|4 7|1 8|5|3 9|6
+-         -     -
+8 3 2|9 4 6|1 5 7
+    -         -
+5 1|9|3 7 2|6|8|4
+  -   -----     -
+9|7 6 5|3 4|8|2 1
+-           -
+3 2 8 4|9 1|7 6 5
+  -----
+1|8 5 2 6 7|9 4 3
+`
+	if actual := solved.String(); actual != expected {
+		t.Error("Expected\n", expected, "but got\n", actual)
+	}
+
+	again, err := opts.Parse(expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.String() != expected {
+		t.Error("Expected the borders to be ignored when parsing, but got\n", again)
+	}
+}
+
+func TestJigsawExplain(t *testing.T) {
+	s, err := ParseOptions{Regions: jigsawRegions}.Parse(jigsawSudoku)
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps, _ := s.Explain()
+	for _, st := range steps {
+		if strings.Contains(st.String(), " in region ") {
+			return
+		}
+	}
+	t.Error("Expected the regions to be used, but got", steps)
+}
+
+func TestJigsawSmall(t *testing.T) {
+	opts := ParseOptions{BoxSize: 2, Regions: "AABB ACCB ACCB DDDD"}
+	s, err := opts.Parse("1..." + "...." + "...." + "...4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	solved, err := s.Solve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertIsSolved(solved, t)
+
+	if _, err := (ParseOptions{Regions: "AABB ACCB ACCB DDDD"}).Parse(""); err != ErrInvalidConstraints {
+		t.Error("Expected ErrInvalidConstraints for regions of the wrong size, but got", err)
+	}
+}
+
+func TestJigsawInvalid(t *testing.T) {
+	boxes := strings.Repeat("AAABBBCCC", 3) + strings.Repeat("DDDEEEFFF", 3) + strings.Repeat("GGGHHHIII", 3)
+	if _, err := Jigsaw(boxes); err != nil {
+		t.Fatal("Expected the standard boxes to be valid, but got", err)
+	}
+
+	cases := map[string]string{
+		"too short":    boxes[1:],
+		"too few":      strings.Replace(boxes, "I", "H", -1),
+		"too large":    "B" + boxes[1:],
+		"disconnected": "I" + boxes[1:80] + "A",
+	}
+	for name, regions := range cases {
+		if _, err := Jigsaw(regions); err != ErrInvalidRegions {
+			t.Error("Expected ErrInvalidRegions for", name, "regions, but got", err)
+		}
+	}
+}
+
+const windokuRegions = `.........
+.aaa.bbb.
+.aaa.bbb.
+.aaa.bbb.
+.........
+.ccc.ddd.
+.ccc.ddd.
+.ccc.ddd.
+.........`
+
+func TestExtraRegions(t *testing.T) {
+	opts := ParseOptions{ExtraRegions: windokuRegions}
+	s, err := opts.Parse(windoku)
+	if err != nil {
+		t.Fatal(err)
+	}
+	windoku, err := ParseOptions{Variant: Windoku}.Parse(windoku)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	solved, err := s.Solve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := windoku.Solve()
+	if solved.AsInts() != expected.AsInts() {
+		t.Error("Expected the same solution as the windoku, but got\n", solved)
+	}
+
+	str := solved.String()
+	appendix := str[strings.Index(str, "\n\n")+2:]
+	if !strings.HasPrefix(appendix, ". . . |. . . |. . .\n. a a |a . b |b b .\n") {
+		t.Error("Expected the regions below the grid, but got\n", str)
+	}
+	again, err := ParseOptions{ExtraRegions: appendix}.Parse(str)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.String() != str {
+		t.Error("Expected the regions to be read again, but got\n", again)
+	}
+}
+
+func TestExtraRegionsInvalid(t *testing.T) {
+	cases := map[string]string{
+		"bad count": windokuRegions[10:],
+		"small":     strings.Replace(windokuRegions, "a", ".", 1),
+		"large":     strings.Replace(windokuRegions, ".", "a", 1),
+	}
+	for name, regions := range cases {
+		if _, err := ExtraRegions(regions); err != ErrInvalidRegions {
+			t.Error(name+": expected ErrInvalidRegions, but got", err)
+		}
+	}
+
+	if _, err := (ParseOptions{ExtraRegions: "aaaa bbbb cccc dddd"}).Parse(windoku); err != ErrInvalidConstraints {
+		t.Error("Expected ErrInvalidConstraints for regions of another size, but got", err)
+	}
+}
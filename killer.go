@@ -0,0 +1,226 @@
+package sudoku
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Killer sudokus
+
+var (
+	// ErrInvalidCages is returned by Killer if the cages can't be read or
+	// don't fit the board.
+	ErrInvalidCages = fmt.Errorf("Invalid cages")
+)
+
+// Killer returns a constraint for the cages of a killer sudoku. The values in
+// a cage add up to its sum, and no value is repeated within a cage. The cages
+// are given as a grid with a label for every square, followed by the sum of
+// every cage, for example
+//
+//	aabbcddee
+//	...
+//	a=8 b=15 c=7 d=11 e=9 ...
+//
+// Letters and digits are labels, a dot marks a square without a cage. All
+// other runes in the grid are ignored, so that the output of String can be
+// read again. Every label needs a sum, and no cage can be larger than a
+// unit. Otherwise ErrInvalidCages is returned.
+//
+// When the sudoku is printed, the cages and their sums are added below the
+// grid in the same format.
+func Killer(cages string) (Constraint, error) {
+	var labels []rune
+	sums := make(map[rune]int)
+	for _, field := range strings.Fields(cages) {
+		if i := strings.IndexRune(field, '='); i >= 0 {
+			label := []rune(field[:i])
+			sum, err := strconv.Atoi(field[i+1:])
+			if err != nil || len(label) != 1 || sum < 1 {
+				return nil, ErrInvalidCages
+			}
+			sums[label[0]] = sum
+			continue
+		}
+		for _, r := range field {
+			if r == '.' || strings.ContainsRune(cageLabels, r) {
+				labels = append(labels, r)
+			}
+		}
+	}
+
+	size := 0
+	for box := 2; box < len(layouts); box++ {
+		if box*box*box*box == len(labels) {
+			size = box * box
+		}
+	}
+	if size == 0 {
+		return nil, ErrInvalidCages
+	}
+
+	k := killer{size: size, labels: labels, cageOf: make([]int, len(labels))}
+	index := make(map[rune]int)
+	for c, label := range labels {
+		k.cageOf[c] = -1
+		if label == '.' {
+			continue
+		}
+		i, ok := index[label]
+		if !ok {
+			sum, ok := sums[label]
+			if !ok {
+				return nil, ErrInvalidCages
+			}
+			i = len(k.cages)
+			index[label] = i
+			k.cages = append(k.cages, cage{label: label, sum: sum})
+		}
+		k.cages[i].cells = append(k.cages[i].cells, coordinate(c))
+		k.cageOf[c] = i
+	}
+	for label := range sums {
+		if _, ok := index[label]; !ok {
+			return nil, ErrInvalidCages
+		}
+	}
+	for _, cg := range k.cages {
+		if len(cg.cells) > size {
+			return nil, ErrInvalidCages
+		}
+	}
+	return k, nil
+}
+
+// cageLabels are the runes that can be used as labels.
+const cageLabels = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// A cage is a group of squares whose values add up to sum.
+type cage struct {
+	label rune
+	sum   int
+	cells []coordinate
+}
+
+type killer struct {
+	size   int
+	labels []rune
+	cages  []cage
+	// cageOf holds the index of the cage of every square, -1 if there is
+	// none.
+	cageOf []int
+}
+
+func (k killer) units(l *layout) [][]coordinate {
+	return nil
+}
+
+// peers returns the other squares of the cage, since values can't repeat
+// within a cage. Cages for another size are reported by restrict.
+func (k killer) peers(l *layout, c coordinate) []coordinate {
+	if l.size != k.size {
+		return nil
+	}
+	i := k.cageOf[c]
+	if i < 0 {
+		return nil
+	}
+	return k.cages[i].cells
+}
+
+func (k killer) restrict(p propagator) error {
+	if p.s.layout.size != k.size {
+		return ErrInvalidConstraints
+	}
+	for i := range k.cages {
+		if err := k.restrictCage(p, i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (k killer) propagate(p propagator, c coordinate, sv uint8) error {
+	if i := k.cageOf[c]; i >= 0 {
+		return k.restrictCage(p, i)
+	}
+	return nil
+}
+
+// restrictCage eliminates all values from the empty squares of the cage that
+// are not part of a combination adding up to what is left of the sum.
+func (k killer) restrictCage(p propagator, i int) error {
+	cg := k.cages[i]
+	sum, open := cg.sum, 0
+	var allowed uint32
+	for _, c := range cg.cells {
+		if v := p.s.value(c); v != 0 {
+			sum -= int(v)
+		} else {
+			open++
+			allowed |= p.s.candidates(c)
+		}
+	}
+	if open == 0 {
+		if sum != 0 {
+			return ErrConflict
+		}
+		return nil
+	}
+
+	possible := combinationValues(allowed, open, sum)
+	if possible == 0 {
+		return ErrConflict
+	}
+	for _, c := range cg.cells {
+		for _, sv := range valuesOf(p.s.candidates(c) &^ possible) {
+			if err := p.eliminate(c, sv); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// combinationValues returns the values that are part of some combination of
+// n different values from the bitset allowed that add up to sum.
+func combinationValues(allowed uint32, n, sum int) uint32 {
+	var res uint32
+	var rec func(from, n, sum int, used uint32)
+	rec = func(from, n, sum int, used uint32) {
+		if n == 0 {
+			if sum == 0 {
+				res |= used
+			}
+			return
+		}
+		// stop if even the smallest values left are too large, or if every
+		// value is known to be possible anyway
+		if n*from+n*(n-1)/2 > sum || res == allowed {
+			return
+		}
+		for v := from; v < 32 && v <= sum; v++ {
+			if allowed&(1<<uint(v)) != 0 {
+				rec(v+1, n-1, sum-v, used|1<<uint(v))
+			}
+		}
+	}
+	rec(1, n, sum, 0)
+	return res
+}
+
+// appendix describes the cages in the format read by Killer.
+func (k killer) appendix(l *layout) string {
+	res := l.grid(func(c coordinate) string {
+		return string(k.labels[c])
+	}, false)
+
+	sums := make([]string, len(k.cages))
+	for i, cg := range k.cages {
+		sums[i] = fmt.Sprintf("%c=%d", cg.label, cg.sum)
+	}
+	sort.Strings(sums)
+	return res + strings.Join(sums, " ") + "\n"
+}
@@ -0,0 +1,137 @@
+package sudoku
+
+import (
+	"strings"
+	"testing"
+)
+
+const killerCages = `llennnppm
+Beenwnppm
+Bddddvssm
+Bdqqivssm
+Bggqiixoo
+uhhhrrfyo
+uubbkkffj
+uzctaaajj
+ucccAAAjj
+a=11 b=15 c=16 d=28 e=19 f=16 g=3 h=11 i=22 j=31 k=7 l=5 m=14 n=27
+o=16 p=22 q=12 r=12 s=20 t=6 u=23 v=4 w=2 x=8 y=1 z=7 A=17 B=30`
+
+func TestKiller(t *testing.T) {
+	opts := ParseOptions{Cages: killerCages}
+	s, err := opts.Parse(strings.Repeat(".", 81))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.IsUnique() {
+		t.Error("Expected a unique solution")
+	}
+
+	solved, err := s.Solve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertIsSolved(solved, t)
+
+	expected := `1 4 9 |8 7 5 |6 3 2
+8 3 7 |1 2 6 |4 9 5
+6 5 2 |9 4 3 |7 8 1
+------+------+------
+7 8 4 |5 9 1 |3 2 6
+9 2 1 |3 6 7 |8 5 4
+5 6 3 |2 8 4 |9 1 7
+------+------+------
+4 9 8 |7 5 2 |1 6 3
+3 7 5 |6 1 8 |2 4 9
+2 1 6 |4 3 9 |5 7 8
+
+l l e |n n n |p p m
+B e e |n w n |p p m
+B d d |d d v |s s m
+------+------+------
+B d q |q i v |s s m
+B g g |q i i |x o o
+u h h |h r r |f y o
+------+------+------
+u u b |b k k |f f j
+u z c |t a a |a j j
+u c c |c A A |A j j
+A=17 B=30 a=11 b=15 c=16 d=28 e=19 f=16 g=3 h=11 i=22 j=31 k=7 l=5 m=14 n=27 o=16 p=22 q=12 r=12 s=20 t=6 u=23 v=4 w=2 x=8 y=1 z=7
+`
+	if actual := solved.String(); actual != expected {
+		t.Error("Expected\n", expected, "but got\n", actual)
+	}
+
+	appendix := expected[strings.Index(expected, "\n\n")+2:]
+	again, err := ParseOptions{Cages: appendix}.Parse(expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.String() != expected {
+		t.Error("Expected the cages to be read again, but got\n", again)
+	}
+}
+
+func TestKillerConflict(t *testing.T) {
+	s, err := ParseOptions{Cages: killerCages}.Parse(strings.Repeat(".", 81))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the cage w=2 can't be anything but 2
+	if v := s.AsInts()[1][4]; v != 2 {
+		t.Error("Expected B5 to be filled in, but got", v)
+	}
+	// the cage g=3 holds 1 and 2
+	if _, err := s.WithCellValued('E', '2', 3); err != ErrConflict {
+		t.Error("Expected ErrConflict in a cage, but got", err)
+	}
+}
+
+func TestKillerInvalid(t *testing.T) {
+	cases := map[string]string{
+		"missing sum": strings.Replace(killerCages, " B=30", "", 1),
+		"unused sum":  killerCages + " C=5",
+		"bad sum":     strings.Replace(killerCages, "B=30", "B=x", 1),
+		"bad label":   strings.Replace(killerCages, "B=30", "BB=30", 1),
+		"bad count":   killerCages[10:],
+		"too large":   strings.Repeat("aaaaaaaaa\n", 2) + strings.Repeat(".........\n", 7) + "a=90",
+	}
+	for name, cages := range cases {
+		if _, err := Killer(cages); err != ErrInvalidCages {
+			t.Error(name+": expected ErrInvalidCages, but got", err)
+		}
+	}
+
+	if _, err := (ParseOptions{BoxSize: 2, Cages: killerCages}).Parse("................"); err != ErrInvalidConstraints {
+		t.Error("Expected ErrInvalidConstraints for cages of another size, but got", err)
+	}
+}
+
+func TestCombinationValues(t *testing.T) {
+	all := layouts[3].allValues
+	cases := []struct {
+		allowed  uint32
+		n, sum   int
+		expected []uint8
+	}{
+		{all, 2, 3, []uint8{1, 2}},
+		{all, 2, 17, []uint8{8, 9}},
+		{all, 3, 6, []uint8{1, 2, 3}},
+		{all, 2, 10, []uint8{1, 2, 3, 4, 6, 7, 8, 9}},
+		{all &^ (1 << 9), 2, 17, nil},
+		{all, 4, 9, nil},
+	}
+	for _, c := range cases {
+		actual := valuesOf(combinationValues(c.allowed, c.n, c.sum))
+		if len(actual) != len(c.expected) {
+			t.Error("Expected", c.expected, "for", c.n, "values adding up to", c.sum, "but got", actual)
+			continue
+		}
+		for i := range actual {
+			if actual[i] != c.expected[i] {
+				t.Error("Expected", c.expected, "for", c.n, "values adding up to", c.sum, "but got", actual)
+				break
+			}
+		}
+	}
+}
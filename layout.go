@@ -0,0 +1,261 @@
+package sudoku
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Layouts
+//
+// A layout describes the shape of a board: how many squares there are, which
+// units they form and which squares are peers of each other. Layouts are
+// computed once and shared by all sudokus of that shape, they are never
+// modified.
+type layout struct {
+	// box is the number of rows and columns in a box, size the number of
+	// rows, columns and values, which is box*box.
+	box, size, numCells int
+	// constraints are the rules the layout was computed from.
+	constraints []Constraint
+
+	// units are the groups of squares that have to contain each value
+	// exactly once. The first size units are the rows, followed by the
+	// columns and the boxes. Any further units come from other constraints.
+	units [][]coordinate
+	// unitNames are the names of the units, as used in explanations.
+	unitNames []string
+	// unitsOf lists the units of each square, starting with its row, column
+	// and box.
+	unitsOf [][]int
+	// peers lists the peers of each square, see addPeers.
+	peers [][]coordinate
+	// isPeer tells whether two squares are peers of each other.
+	isPeer [][]bool
+	// unitSearchOrder is the order in which units are looked at. Boxes come
+	// first, since most people find patterns there more easily, followed by
+	// the rows, columns and the remaining units.
+	unitSearchOrder []int
+
+	// allValues has the bits for the values 1 to size set.
+	allValues uint32
+	// digits are the runes used for the values, the first one standing for an
+	// empty square.
+	digits string
+	// names are the names of the squares on boards that aren't square, such
+	// as samurai sudokus. Otherwise it is nil, and they follow from size.
+	names []string
+}
+
+var (
+	// ErrUnsupportedSize is returned when parsing with a box size that is not
+	// supported.
+	ErrUnsupportedSize = fmt.Errorf("Unsupported size")
+	// ErrInvalidConstraints is returned when parsing with constraints that
+	// don't meet the requirements described for Constraint.
+	ErrInvalidConstraints = fmt.Errorf("Invalid constraints")
+)
+
+// layouts holds the layouts of the classic rules, indexed by box size.
+var layouts [6]*layout
+
+func init() {
+	for box := 2; box < len(layouts); box++ {
+		layouts[box], _ = newLayout(box, Classic())
+	}
+}
+
+// layoutFor returns the layout for the given box size and constraints, nil
+// meaning the classic rules.
+func layoutFor(box int, constraints []Constraint) (*layout, error) {
+	if box < 2 || box >= len(layouts) {
+		return nil, ErrUnsupportedSize
+	}
+	if constraints == nil {
+		return layouts[box], nil
+	}
+	return newLayout(box, constraints)
+}
+
+func newLayout(box int, constraints []Constraint) (*layout, error) {
+	size := box * box
+	l := &layout{
+		box:         box,
+		size:        size,
+		numCells:    size * size,
+		constraints: constraints,
+		unitsOf:     make([][]int, size*size),
+		allValues:   1<<uint(size+1) - 2,
+	}
+
+	switch {
+	case size <= 9:
+		l.digits = "." + "123456789"[:size]
+	case size == 16:
+		l.digits = ".123456789ABCDEFG"
+	default:
+		l.digits = ".ABCDEFGHIJKLMNOPQRSTUVWXY"
+	}
+
+	if len(constraints) < 3 || constraints[0] != Rows || constraints[1] != Columns {
+		return nil, ErrInvalidConstraints
+	}
+	for _, con := range constraints {
+		for i, unit := range con.units(l) {
+			name := fmt.Sprintf("unit %d", len(l.units)+1)
+			if n, ok := con.(unitNamer); ok {
+				name = n.unitName(l, i)
+			}
+			l.unitNames = append(l.unitNames, name)
+
+			if len(unit) != size {
+				return nil, ErrInvalidConstraints
+			}
+			for _, c := range unit {
+				if int(c) >= l.numCells {
+					return nil, ErrInvalidConstraints
+				}
+				l.unitsOf[c] = append(l.unitsOf[c], len(l.units))
+			}
+			l.units = append(l.units, unit)
+		}
+	}
+	// the third constraint has to divide the board into boxes, so that every
+	// square is in exactly one row, column and box
+	for _, u := range l.unitsOf {
+		if len(u) < 3 || u[2] >= 3*size || (len(u) > 3 && u[3] < 3*size) {
+			return nil, ErrInvalidConstraints
+		}
+	}
+
+	l.addPeers()
+
+	for u := 2 * size; u < 3*size; u++ {
+		l.unitSearchOrder = append(l.unitSearchOrder, u)
+	}
+	for u := range l.units {
+		if u < 2*size || u >= 3*size {
+			l.unitSearchOrder = append(l.unitSearchOrder, u)
+		}
+	}
+	return l, nil
+}
+
+// addPeers computes the peers from the units and constraints. A peer is any
+// cell that is influenced by the key, for example A1 is peer of A2, A3, B1, B3
+// etc, but not of D9. The peers are kept in a fixed order, so that
+// propagation always happens in the same order: first the column, then the
+// row, then the rest of the box, followed by the other units and the peers
+// added by constraints.
+func (l *layout) addPeers() {
+	l.peers = make([][]coordinate, l.numCells)
+	l.isPeer = make([][]bool, l.numCells)
+	for c := range l.isPeer {
+		l.isPeer[c] = make([]bool, l.numCells)
+	}
+
+	add := func(c, p coordinate) {
+		if p != c && !l.isPeer[c][p] {
+			l.isPeer[c][p] = true
+			l.peers[c] = append(l.peers[c], p)
+		}
+	}
+	for c := range l.peers {
+		units := append([]int{l.unitsOf[c][1], l.unitsOf[c][0]}, l.unitsOf[c][2:]...)
+		for _, u := range units {
+			for _, p := range l.units[u] {
+				add(coordinate(c), p)
+			}
+		}
+		for _, con := range l.constraints {
+			for _, p := range con.peers(l, coordinate(c)) {
+				add(coordinate(c), p)
+			}
+		}
+	}
+}
+
+// empty returns a sudoku without any values, in which the constraints have
+// eliminated what they rule out from the start.
+func (l *layout) empty() (Sudoku, error) {
+	s := Sudoku{layout: l}.clone()
+	for _, con := range l.constraints {
+		if err := con.restrict(propagator{&s, nil}); err != nil {
+			return s, err
+		}
+	}
+	return s, nil
+}
+
+// coord returns the coordinate of the square in the given row and column,
+// both counting from zero.
+func (l *layout) coord(row, col int) coordinate {
+	return coordinate(row*l.size + col)
+}
+
+// name returns the name of the square at c, the row as letter followed by
+// the column as number, as in A1 or P16.
+func (l *layout) name(c coordinate) string {
+	if l.names != nil {
+		return l.names[c]
+	}
+	return fmt.Sprintf("%c%d", 'A'+int(c)/l.size, int(c)%l.size+1)
+}
+
+// unitName returns the name of the unit u, as in "row A" or "box 5".
+func (l *layout) unitName(u int) string {
+	return l.unitNames[u]
+}
+
+// mark returns the rune printed after the square at c by String, a space
+// unless some constraint marks the square. If marks is not set, it is always
+// a space.
+func (l *layout) mark(c coordinate, marks bool) (byte, bool) {
+	for _, con := range l.constraints {
+		if !marks {
+			break
+		}
+		if m, ok := con.(marker); ok {
+			if mark, marked := m.mark(l, c); marked {
+				return mark, true
+			}
+		}
+	}
+	return ' ', false
+}
+
+// frame returns the clues shown above every column and beside every row by
+// String, nil if there are none. If marks is not set, there are none either.
+func (l *layout) frame(marks bool) (columns, rows []string) {
+	if !marks {
+		return nil, nil
+	}
+	for _, con := range l.constraints {
+		if f, ok := con.(framer); ok {
+			return f.frame(l)
+		}
+	}
+	return nil, nil
+}
+
+// valueOf returns the value the given rune stands for, zero if it doesn't
+// stand for one. Letters are accepted in upper and lower case.
+func (l *layout) valueOf(r rune) uint8 {
+	return uint8(strings.IndexRune(l.digits[1:], unicode.ToUpper(r)) + 1)
+}
+
+// Coordinates are the indices in the cells slice. A uint16 is sufficient for
+// the 625 squares of the largest board.
+type coordinate uint16
+
+// coord returns the coordinate of a square of a standard sudoku, given its
+// row and column in A1..I9 notation.
+func coord(r, c rune) coordinate {
+	return coordinate(uint16(r-'A')*9 + uint16(c-'1'))
+}
+
+// String returns the coordinate in A1..I9 notation, assuming a standard
+// sudoku.
+func (c coordinate) String() string {
+	return layouts[3].name(c)
+}
@@ -0,0 +1,145 @@
+package sudoku
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// assertIsSolved checks every unit of a sudoku of any size.
+func assertIsSolved(s Sudoku, t *testing.T) {
+	l := s.shape()
+	for u, unit := range l.units {
+		var seen uint32
+		for _, c := range unit {
+			seen |= 1 << s.value(c)
+		}
+		if seen != l.allValues {
+			t.Error("Expected", l.unitName(u), "to contain every value in\n", s)
+		}
+	}
+}
+
+func TestLayouts(t *testing.T) {
+	for box := 2; box <= 5; box++ {
+		l := layouts[box]
+		if len(l.units) != 3*l.size {
+			t.Error("Expected", 3*l.size, "units, but got", len(l.units))
+		}
+		if expected := 2*(l.size-1) + (box-1)*(box-1); len(l.peers[0]) != expected {
+			t.Error("Expected", expected, "peers, but got", len(l.peers[0]))
+		}
+		if n := len(l.digits) - 1; n != l.size {
+			t.Error("Expected", l.size, "digits, but got", n)
+		}
+	}
+}
+
+const hexadoku = `......6.E42..D...F.D..4E6....G..315.F..D9..B.A....E679.2....3...
+.D.5.....8.G4..F.....G.8.C.E..BD..9.41C3..F.E75....7..E...56....
+....98...2..C....834.2..59AC.B..B9..3.D.F.4.....5..CG.F.....1.8.
+...8....G.E2D3....2.8..F3..A.E4C..D....187..5.F...C..625.D......`
+
+func TestHexadoku(t *testing.T) {
+	s, err := ParseOptions{BoxSize: 4}.Parse(hexadoku)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.IsUnique() {
+		t.Error("Expected a unique solution")
+	}
+
+	solved, err := s.Solve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertIsSolved(solved, t)
+	if solved.Values()[0][6] != 6 || solved.Values()[0][8] != 14 {
+		t.Error("Expected the clues to be kept, but got\n", solved)
+	}
+
+	lower, err := ParseOptions{BoxSize: 4}.Parse(strings.ToLower(hexadoku))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lower.String() != s.String() {
+		t.Error("Expected lower case letters to be accepted")
+	}
+}
+
+func TestSolveLargestEmpty(t *testing.T) {
+	s, err := ParseOptions{BoxSize: 5}.Parse(strings.Repeat(".", 625))
+	if err != nil {
+		t.Fatal(err)
+	}
+	solved, err := s.Solve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertIsSolved(solved, t)
+	if strings.ContainsAny(solved.String(), ".0123456789") {
+		t.Error("Expected letters as values, but got\n", solved)
+	}
+}
+
+func TestParseUnsupportedSize(t *testing.T) {
+	for _, box := range []int{-1, 1, 6} {
+		if _, err := (ParseOptions{BoxSize: box}).Parse(""); err != ErrUnsupportedSize {
+			t.Error("Expected ErrUnsupportedSize for", box, "but got", err)
+		}
+	}
+}
+
+func TestWithValueAt(t *testing.T) {
+	s, err := ParseOptions{BoxSize: 4}.Parse(hexadoku)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = s.WithValueAt(15, 15, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := s.Values()[15][15]; v != 16 {
+		t.Error("Expected P16 to be 16, but got", v)
+	}
+
+	for _, x := range [][3]int{{16, 0, 1}, {0, -1, 1}, {0, 0, 17}} {
+		if _, err := s.WithValueAt(x[0], x[1], uint8(x[2])); err != ErrConflict {
+			t.Error("Expected ErrConflict for", x, "but got", err)
+		}
+	}
+}
+
+func TestExplainNames(t *testing.T) {
+	s, err := ParseOptions{BoxSize: 4}.Parse(hexadoku)
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps, _ := s.Explain()
+	for _, st := range steps {
+		for _, c := range st.Cells {
+			if c[0] > 'P' {
+				t.Fatal("Unexpected square", c, "in", st)
+			}
+		}
+	}
+}
+
+func ExampleParseOptions() {
+	s, err := ParseOptions{BoxSize: 2}.Parse("..41........34..")
+	if err != nil {
+		panic(err)
+	}
+	solved, err := s.Solve()
+	if err != nil {
+		panic(err)
+	}
+	fmt.Print(solved)
+	// Output:
+	// 2 3 |4 1
+	// 4 1 |2 3
+	// ----+----
+	// 1 2 |3 4
+	// 3 4 |1 2
+}
@@ -0,0 +1,322 @@
+package sudoku
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/bits"
+)
+
+// Line constraints
+
+var (
+	// ErrInvalidLines is returned by Lines if the lines can't be read or
+	// don't connect their squares.
+	ErrInvalidLines = fmt.Errorf("Invalid lines")
+)
+
+// Lines returns a constraint for lines drawn across the board, read from a
+// JSON object such as
+//
+//	{
+//		"thermometers": [["A1", "A2", "B3"]],
+//		"arrows": [["E5", "E6", "F7"]],
+//		"greaterThan": [["C4", "C5"]],
+//		"renban": [["G1", "H2", "I3"]],
+//		"whispers": [["A7", "A8", "A9"]],
+//		"palindromes": [["B3", "C4", "D5", "E6", "F7"]]
+//	}
+//
+// The values along a thermometer increase strictly, starting at the bulb,
+// which is the first square. The first square of an arrow is its circle,
+// which contains the sum of the values along the arrow. Those values can
+// repeat, unless the rules forbid it otherwise. A greater-than sign between
+// two orthogonally adjacent squares requires the first value to be greater
+// than the second.
+//
+// A renban line contains a set of consecutive values in any order, without
+// repeats. Neighbours along German whispers differ by at least 5, or half
+// the number of values on boards other than 9x9. A palindrome line reads the
+// same in both directions.
+//
+// The squares of all lines but greater-than signs have to be connected
+// horizontally, vertically or diagonally, and no square can be used twice by
+// a line. Otherwise ErrInvalidLines is returned.
+//
+// When the sudoku is printed, the lines are added below the grid in the same
+// format.
+func Lines(spec string) (Constraint, error) {
+	var res lineSet
+	if err := json.Unmarshal([]byte(spec), &res.spec); err != nil {
+		return nil, ErrInvalidLines
+	}
+
+	var err error
+	read := func(names []string, min int, connected func(a, b position) bool) []position {
+		if len(names) < min {
+			err = ErrInvalidLines
+		}
+		var line []position
+		seen := make(map[position]bool)
+		for i, name := range names {
+			pos, ok := parsePosition(name)
+			if !ok || seen[pos] || i > 0 && !connected(line[i-1], pos) {
+				err = ErrInvalidLines
+			}
+			seen[pos] = true
+			line = append(line, pos)
+		}
+		return line
+	}
+
+	add := func(kind lineKind, lines [][]string) {
+		for _, names := range lines {
+			res.lines = append(res.lines, line{kind, read(names, 2, position.touches)})
+		}
+	}
+	add(thermometer, res.spec.Thermometers)
+	add(arrow, res.spec.Arrows)
+	add(renban, res.spec.Renban)
+	add(whispers, res.spec.Whispers)
+	add(palindrome, res.spec.Palindromes)
+	for _, names := range res.spec.GreaterThan {
+		squares := read(names, 2, position.adjacent)
+		if len(names) != 2 {
+			err = ErrInvalidLines
+		} else {
+			// a greater-than sign is a thermometer from the smaller value
+			squares[0], squares[1] = squares[1], squares[0]
+		}
+		res.lines = append(res.lines, line{thermometer, squares})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// touches reports whether both positions are next to each other, including
+// diagonally.
+func (pos position) touches(other position) bool {
+	dr, dc := pos.row-other.row, pos.col-other.col
+	return pos != other && dr >= -1 && dr <= 1 && dc >= -1 && dc <= 1
+}
+
+// linesSpec is the JSON format read by Lines.
+type linesSpec struct {
+	Thermometers [][]string `json:"thermometers,omitempty"`
+	Arrows       [][]string `json:"arrows,omitempty"`
+	GreaterThan  [][]string `json:"greaterThan,omitempty"`
+	Renban       [][]string `json:"renban,omitempty"`
+	Whispers     [][]string `json:"whispers,omitempty"`
+	Palindromes  [][]string `json:"palindromes,omitempty"`
+}
+
+// The kinds of lines. Greater-than signs are thermometers of two squares.
+type lineKind int
+
+const (
+	thermometer lineKind = iota
+	arrow
+	renban
+	whispers
+	palindrome
+)
+
+type line struct {
+	kind    lineKind
+	squares []position
+}
+
+type lineSet struct {
+	spec  linesSpec
+	lines []line
+}
+
+func (ls lineSet) units(l *layout) [][]coordinate {
+	return nil
+}
+
+// peers returns the other squares of the renban lines through c, since
+// values can't repeat on them.
+func (ls lineSet) peers(l *layout, c coordinate) []coordinate {
+	var res []coordinate
+	for _, ln := range ls.lines {
+		if cells, ok := lineCells(l, ln.squares); ok && ln.kind == renban && contains(cells, c) {
+			res = append(res, cells...)
+		}
+	}
+	return res
+}
+
+// lineCells returns the coordinates of the line in l.
+func lineCells(l *layout, line []position) ([]coordinate, bool) {
+	res := make([]coordinate, len(line))
+	for i, pos := range line {
+		c, ok := pos.in(l)
+		if !ok {
+			return nil, false
+		}
+		res[i] = c
+	}
+	return res, true
+}
+
+func (ls lineSet) restrict(p propagator) error {
+	for _, ln := range ls.lines {
+		cells, ok := lineCells(p.s.layout, ln.squares)
+		if !ok {
+			return ErrInvalidConstraints
+		}
+		if err := ln.restrict(p, cells); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ls lineSet) propagate(p propagator, c coordinate, sv uint8) error {
+	for _, ln := range ls.lines {
+		if cells, _ := lineCells(p.s.layout, ln.squares); contains(cells, c) {
+			if err := ln.restrict(p, cells); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// restrict eliminates the values that the line rules out, cells being its
+// squares.
+func (ln line) restrict(p propagator, cells []coordinate) error {
+	switch ln.kind {
+	case thermometer:
+		return p.increase(cells)
+	case arrow:
+		return p.sumUp(cells[0], cells[1:])
+	case renban:
+		return p.consecutiveSet(cells)
+	case whispers:
+		apart := farApart(uint8(p.s.layout.size+1) / 2)
+		for i := 1; i < len(cells); i++ {
+			if err := p.relate(cells[i-1], cells[i], apart); err != nil {
+				return err
+			}
+		}
+	case palindrome:
+		for i := 0; i < len(cells)/2; i++ {
+			if err := p.relate(cells[i], cells[len(cells)-1-i], equal); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func contains(cells []coordinate, c coordinate) bool {
+	for _, other := range cells {
+		if other == c {
+			return true
+		}
+	}
+	return false
+}
+
+// appendix describes the lines in the format read by Lines.
+func (ls lineSet) appendix(l *layout) string {
+	res, _ := json.Marshal(ls.spec)
+	return string(res) + "\n"
+}
+
+// Bounds propagation
+
+// bounds returns the lowest and highest value possible in the square at c.
+func (p propagator) bounds(c coordinate) (int, int) {
+	possible := p.possible(c)
+	if possible == 0 {
+		return 0, -1
+	}
+	return bits.TrailingZeros32(possible), 31 - bits.LeadingZeros32(possible)
+}
+
+// bound eliminates all values below lo and above hi from the square at c.
+func (p propagator) bound(c coordinate, lo, hi int) error {
+	for _, sv := range valuesOf(p.possible(c)) {
+		if int(sv) < lo || int(sv) > hi {
+			if err := p.eliminate(c, sv); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// increase restricts the squares to strictly increasing values: every square
+// has to be above the lowest value of the one before, and below the highest
+// value of the one after.
+func (p propagator) increase(cells []coordinate) error {
+	for i := 1; i < len(cells); i++ {
+		lo, _ := p.bounds(cells[i-1])
+		if err := p.bound(cells[i], lo+1, p.s.layout.size); err != nil {
+			return err
+		}
+	}
+	for i := len(cells) - 2; i >= 0; i-- {
+		_, hi := p.bounds(cells[i+1])
+		if err := p.bound(cells[i], 1, hi-1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// consecutiveSet restricts the squares to a set of consecutive values. Every
+// value kept is part of some run of as many values as there are squares, in
+// which every square has a value possible, and every value of the run is
+// possible in some square.
+func (p propagator) consecutiveSet(cells []coordinate) error {
+	var supported uint32
+	run := uint32(1)<<uint(len(cells)) - 1
+	for start := 1; start+len(cells)-1 <= p.s.layout.size; start++ {
+		window := run << uint(start)
+		var union uint32
+		fits := true
+		for _, c := range cells {
+			possible := p.possible(c) & window
+			fits = fits && possible != 0
+			union |= possible
+		}
+		if fits && union == window {
+			supported |= window
+		}
+	}
+
+	for _, c := range cells {
+		if err := p.restrictTo(c, supported); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sumUp restricts the squares so that the value of total can be the sum of
+// the values of the parts.
+func (p propagator) sumUp(total coordinate, parts []coordinate) error {
+	min, max := 0, 0
+	for _, c := range parts {
+		lo, hi := p.bounds(c)
+		min, max = min+lo, max+hi
+	}
+	if err := p.bound(total, min, max); err != nil {
+		return err
+	}
+
+	lo, hi := p.bounds(total)
+	for _, c := range parts {
+		partLo, partHi := p.bounds(c)
+		// the other parts take at least min-partLo and at most max-partHi
+		if err := p.bound(c, lo-(max-partHi), hi-(min-partLo)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
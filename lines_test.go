@@ -0,0 +1,220 @@
+package sudoku
+
+import (
+	"strings"
+	"testing"
+)
+
+const (
+	thermoLines = `{
+	"thermometers": [["B1", "C1", "D1"], ["G7", "H8", "H9", "G9"], ["E7", "F7", "E6", "F6"], ["H2", "H3", "G3"]],
+	"arrows": [["D9", "C9", "B8"], ["E2", "E3", "E4"], ["H5", "I4", "I3", "H4"]],
+	"greaterThan": [["A4", "B4"], ["C5", "C6"], ["A5", "B5"], ["E9", "F9"]]
+}`
+	thermoSudoku = ".....6....9.7...........4.......5..42............7......................4.16..89."
+)
+
+func TestLines(t *testing.T) {
+	opts := ParseOptions{Lines: thermoLines}
+	s, err := opts.Parse(thermoSudoku)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.IsUnique() {
+		t.Error("Expected a unique solution")
+	}
+	if classic, _ := Parse(thermoSudoku); classic.IsUnique() {
+		t.Error("Expected several solutions without the lines")
+	}
+
+	solved, err := s.Solve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `1 3 5 |8 4 6 |9 7 2
+6 9 4 |7 1 2 |5 3 8
+7 8 2 |9 5 3 |4 6 1
+------+------+------
+9 1 6 |3 2 5 |7 8 4
+2 7 3 |4 6 8 |1 5 9
+5 4 8 |1 7 9 |6 2 3
+------+------+------
+3 6 9 |5 8 4 |2 1 7
+8 5 7 |2 9 1 |3 4 6
+4 2 1 |6 3 7 |8 9 5
+
+{"thermometers":[["B1","C1","D1"],["G7","H8","H9","G9"],["E7","F7","E6","F6"],["H2","H3","G3"]],"arrows":[["D9","C9","B8"],["E2","E3","E4"],["H5","I4","I3","H4"]],"greaterThan":[["A4","B4"],["C5","C6"],["A5","B5"],["E9","F9"]]}
+`
+	if actual := solved.String(); actual != expected {
+		t.Error("Expected\n", expected, "but got\n", actual)
+	}
+
+	appendix := expected[strings.Index(expected, "\n\n")+2:]
+	again, err := ParseOptions{Lines: appendix}.Parse(expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.String() != expected {
+		t.Error("Expected the lines to be read again, but got\n", again)
+	}
+}
+
+func TestLinesPropagate(t *testing.T) {
+	opts := ParseOptions{Lines: `{
+		"thermometers": [["A1", "A2", "A3", "A4"]],
+		"arrows": [["C7", "C8", "C9"]],
+		"greaterThan": [["E5", "E6"]]
+	}`}
+	s, err := opts.Parse(strings.Repeat(".", 81))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := map[coordinate][]uint8{
+		coord('A', '1'): {1, 2, 3, 4, 5, 6},
+		coord('A', '4'): {4, 5, 6, 7, 8, 9},
+		coord('C', '7'): {2, 3, 4, 5, 6, 7, 8, 9},
+		coord('C', '8'): {1, 2, 3, 4, 5, 6, 7, 8},
+		coord('E', '5'): {2, 3, 4, 5, 6, 7, 8, 9},
+		coord('E', '6'): {1, 2, 3, 4, 5, 6, 7, 8},
+	}
+	for c, expected := range cases {
+		if actual := valuesOf(s.candidates(c)); !equalValues(actual, expected) {
+			t.Error("Expected", expected, "in", c, "but got", actual)
+		}
+	}
+
+	if s, err = s.WithCellValued('A', '3', 4); err != nil {
+		t.Fatal(err)
+	}
+	if s, err = s.WithCellValued('C', '7', 4); err != nil {
+		t.Fatal(err)
+	}
+	cases = map[coordinate][]uint8{
+		coord('A', '1'): {1, 2},
+		coord('A', '2'): {2, 3},
+		coord('A', '4'): {5, 6, 7, 8, 9},
+		coord('C', '8'): {1, 2, 3},
+		coord('C', '9'): {1, 2, 3},
+	}
+	for c, expected := range cases {
+		if actual := valuesOf(s.candidates(c)); !equalValues(actual, expected) {
+			t.Error("Expected", expected, "in", c, "but got", actual)
+		}
+	}
+	if _, err := s.WithCellValued('A', '4', 3); err != ErrConflict {
+		t.Error("Expected ErrConflict on the thermometer, but got", err)
+	}
+}
+
+const (
+	modernLines  = `{"renban": [["A1", "B1", "B2"], ["G2", "G3", "G4"]], "whispers": [["C1", "D2", "E3", "F2", "E1"], ["I7", "H7", "G8"]], "palindromes": [["B3", "C3", "D4", "D5", "C6"]]}`
+	modernSudoku = "...1........9...7............9...65.........7.....2........45...9...5...5.......4"
+)
+
+func TestModernLines(t *testing.T) {
+	s, err := ParseOptions{Lines: modernLines}.Parse(modernSudoku)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.IsUnique() {
+		t.Error("Expected a unique solution")
+	}
+	if classic, _ := Parse(modernSudoku); classic.IsUnique() {
+		t.Error("Expected several solutions without the lines")
+	}
+
+	solved, err := s.Solve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `3 8 5 |1 2 7 |4 6 9
+2 4 6 |9 5 3 |8 7 1
+9 7 1 |4 8 6 |3 2 5
+------+------+------
+4 2 9 |7 1 8 |6 5 3
+6 5 8 |3 4 9 |2 1 7
+7 1 3 |5 6 2 |9 4 8
+------+------+------
+1 6 7 |8 3 4 |5 9 2
+8 9 4 |2 7 5 |1 3 6
+5 3 2 |6 9 1 |7 8 4
+`
+	if actual := solved.String(); !strings.HasPrefix(actual, expected) {
+		t.Error("Expected\n", expected, "but got\n", actual)
+	}
+}
+
+func TestModernLinesPropagate(t *testing.T) {
+	opts := ParseOptions{Lines: `{
+		"renban": [["A1", "A2", "A3"]],
+		"whispers": [["D1", "D2", "D3"]],
+		"palindromes": [["E1", "F2", "G3"]]
+	}`}
+	s, err := opts.Parse(strings.Repeat(".", 81))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual := valuesOf(s.candidates(coord('D', '2'))); !equalValues(actual, []uint8{1, 2, 3, 4, 6, 7, 8, 9}) {
+		t.Error("Expected 5 to be eliminated on the whispers, but got", actual)
+	}
+
+	for _, v := range []struct {
+		r, c rune
+		sv   uint8
+	}{{'A', '1', 1}, {'D', '2', 1}, {'E', '1', 5}} {
+		if s, err = s.WithCellValued(v.r, v.c, v.sv); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cases := map[coordinate][]uint8{
+		coord('A', '2'): {2, 3},
+		coord('A', '3'): {2, 3},
+		coord('D', '1'): {6, 7, 8, 9},
+		coord('D', '3'): {6, 7, 8, 9},
+	}
+	for c, expected := range cases {
+		if actual := valuesOf(s.candidates(c)); !equalValues(actual, expected) {
+			t.Error("Expected", expected, "in", c, "but got", actual)
+		}
+	}
+	if v := s.value(coord('G', '3')); v != 5 {
+		t.Error("Expected 5 at the other end of the palindrome, but got", v)
+	}
+
+	if _, err := (ParseOptions{Lines: `{"palindromes": [["A1", "B2", "A3"]]}`}).Parse(strings.Repeat(".", 81)); err != ErrConflict {
+		t.Error("Expected ErrConflict for a palindrome with peers at both ends, but got", err)
+	}
+}
+
+func equalValues(a, b []uint8) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestLinesInvalid(t *testing.T) {
+	cases := []string{
+		`{"thermometers": [["A1", "A3"]]}`,
+		`{"thermometers": [["A1"]]}`,
+		`{"thermometers": [["A1", "A2", "A1"]]}`,
+		`{"arrows": [["A1", "X"]]}`,
+		`{"greaterThan": [["A1", "B2"]]}`,
+		`{"greaterThan": [["A1", "A2", "A3"]]}`,
+		`{"thermometers": "A1"}`,
+		`[`,
+	}
+	for _, lines := range cases {
+		if _, err := Lines(lines); err != ErrInvalidLines {
+			t.Error("Expected ErrInvalidLines for", lines, "but got", err)
+		}
+	}
+	if _, err := (ParseOptions{BoxSize: 2, Lines: `{"arrows": [["A5", "A6"]]}`}).Parse(strings.Repeat(".", 16)); err != ErrInvalidConstraints {
+		t.Error("Expected ErrInvalidConstraints for lines outside the board, but got", err)
+	}
+}
@@ -0,0 +1,810 @@
+package sudoku
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// Logical solving
+//
+// In contrast to Solve, the functions in this file never guess. They apply
+// the techniques a human would use, working on the same bitsets of
+// eliminated values that the search uses. There is one important difference:
+// eliminating values does not propagate automatically here, each step has to
+// be found by one of the techniques.
+
+// A Technique is a named pattern a human solver looks for. The techniques are
+// declared in order of difficulty, which is also the order in which they are
+// tried.
+type Technique uint8
+
+const (
+	// HiddenSingle places a value in the only square of a unit that can take
+	// it.
+	HiddenSingle Technique = iota
+	// NakedSingle places the only value left in a square.
+	NakedSingle
+	// PointingPair eliminates a value from a row or column, because inside a
+	// box it is restricted to that row or column.
+	PointingPair
+	// BoxLineReduction eliminates a value from a box, because inside a row or
+	// column it is restricted to that box.
+	BoxLineReduction
+	// NakedPair eliminates two values from a unit, because two of its squares
+	// can't take anything else.
+	NakedPair
+	// XWing eliminates a value from two columns (rows), because in two rows
+	// (columns) it is restricted to these columns (rows).
+	XWing
+	// HiddenPair eliminates all other values from two squares, because two
+	// values of a unit are restricted to them.
+	HiddenPair
+	// NakedTriple is NakedPair with three squares.
+	NakedTriple
+	// Swordfish is XWing with three rows and columns.
+	Swordfish
+	// HiddenTriple is HiddenPair with three values.
+	HiddenTriple
+	// XYWing eliminates a value seen by both pincers of a pivot square.
+	XYWing
+	// XYZWing eliminates a value seen by the pivot and both pincers.
+	XYZWing
+	// SimpleColouring follows the chains of squares that are the only two
+	// places for a value in a unit.
+	SimpleColouring
+	// NakedQuad is NakedPair with four squares.
+	NakedQuad
+	// Jellyfish is XWing with four rows and columns.
+	Jellyfish
+	// HiddenQuad is HiddenPair with four values.
+	HiddenQuad
+)
+
+var techniqueNames = [...]string{
+	HiddenSingle:     "Hidden Single",
+	NakedSingle:      "Naked Single",
+	PointingPair:     "Pointing Pair",
+	BoxLineReduction: "Box/Line Reduction",
+	NakedPair:        "Naked Pair",
+	XWing:            "X-Wing",
+	HiddenPair:       "Hidden Pair",
+	NakedTriple:      "Naked Triple",
+	Swordfish:        "Swordfish",
+	HiddenTriple:     "Hidden Triple",
+	XYWing:           "XY-Wing",
+	XYZWing:          "XYZ-Wing",
+	SimpleColouring:  "Simple Colouring",
+	NakedQuad:        "Naked Quad",
+	Jellyfish:        "Jellyfish",
+	HiddenQuad:       "Hidden Quad",
+}
+
+func (t Technique) String() string {
+	if int(t) < len(techniqueNames) {
+		return techniqueNames[t]
+	}
+	return fmt.Sprintf("Technique(%d)", uint8(t))
+}
+
+// SolveLogically tries to solve the clues of the receiver without guessing,
+// by repeatedly applying the simplest technique that makes progress. It
+// returns the sudoku as far as it got, and whether that is a complete
+// solution. If the techniques prove that there is no solution, an error is
+// returned.
+//
+// Only the clues are taken into account, values that were filled in by
+// propagation have to be deduced again.
+func (s Sudoku) SolveLogically() (Sudoku, bool, error) {
+	g, _, err := s.deduceLogically()
+	if err != nil {
+		return s, false, err
+	}
+	return g, g.isFilledOut(), nil
+}
+
+// A candidate is a single value for a single square.
+type candidate struct {
+	c  coordinate
+	sv uint8
+}
+
+// A deduction is the result of applying a technique once.
+type deduction struct {
+	technique Technique
+	// units are the units the pattern was found in.
+	units []int
+	// cells are the squares forming the pattern.
+	cells []coordinate
+	// values are the values forming the pattern.
+	values []uint8
+
+	placements   []candidate
+	eliminations []candidate
+}
+
+// techniqueFinders holds a function for each technique that looks for the
+// first occurrence of that technique in a sudoku.
+var techniqueFinders = [...]func(s *Sudoku) (deduction, bool){
+	HiddenSingle:     findHiddenSingle,
+	NakedSingle:      findNakedSingle,
+	PointingPair:     findPointingPair,
+	BoxLineReduction: findBoxLineReduction,
+	NakedPair:        nakedSubsetFinder(NakedPair, 2),
+	XWing:            fishFinder(XWing, 2),
+	HiddenPair:       hiddenSubsetFinder(HiddenPair, 2),
+	NakedTriple:      nakedSubsetFinder(NakedTriple, 3),
+	Swordfish:        fishFinder(Swordfish, 3),
+	HiddenTriple:     hiddenSubsetFinder(HiddenTriple, 3),
+	XYWing:           findXYWing,
+	XYZWing:          findXYZWing,
+	SimpleColouring:  findSimpleColouring,
+	NakedQuad:        nakedSubsetFinder(NakedQuad, 4),
+	Jellyfish:        fishFinder(Jellyfish, 4),
+	HiddenQuad:       hiddenSubsetFinder(HiddenQuad, 4),
+}
+
+// deduceLogically applies the techniques to the clues of the receiver until
+// either the sudoku is solved or none of them makes progress. It returns the
+// final state together with every deduction made on the way.
+func (s Sudoku) deduceLogically() (Sudoku, []deduction, error) {
+	g, err := s.cluesOnly()
+	if err != nil {
+		return s, nil, err
+	}
+
+	var steps []deduction
+	for !g.isFilledOut() {
+		if g.hasContradiction() {
+			return s, steps, ErrConflict
+		}
+
+		d, ok := g.nextDeduction()
+		if !ok {
+			break
+		}
+		if err := g.apply(d); err != nil {
+			return s, steps, err
+		}
+		steps = append(steps, d)
+	}
+	return g, steps, nil
+}
+
+func (s *Sudoku) nextDeduction() (deduction, bool) {
+	for _, find := range techniqueFinders {
+		if d, ok := find(s); ok {
+			return d, true
+		}
+	}
+	return deduction{}, false
+}
+
+func (s *Sudoku) apply(d deduction) error {
+	for _, p := range d.placements {
+		if err := s.place(p.c, p.sv); err != nil {
+			return err
+		}
+	}
+	for _, e := range d.eliminations {
+		s.eliminate(e.c, e.sv)
+	}
+	return nil
+}
+
+// cluesOnly returns a sudoku containing nothing but the clues and masks of
+// the receiver. Each clue eliminates its value from its peers, but in
+// contrast to withAssignment this does not propagate any further.
+func (s Sudoku) cluesOnly() (Sudoku, error) {
+	res := Sudoku{clues: s.clues, masks: s.masks, layout: s.layout}.clone()
+
+	for c, mask := range s.masks {
+		if mask == 0 {
+			continue
+		}
+		for _, sv := range valuesOf(res.shape().allValues &^ mask) {
+			res.eliminate(coordinate(c), sv)
+		}
+	}
+	for c, sv := range s.clues {
+		if sv == 0 {
+			continue
+		}
+		if err := res.place(coordinate(c), sv); err != nil {
+			return s, err
+		}
+	}
+	return res, nil
+}
+
+// Candidate bitsets
+
+// candidates returns the values still possible in the square at c as a
+// bitset, bit n being set if n is possible. Filled squares have none.
+func (s *Sudoku) candidates(c coordinate) uint32 {
+	switch sq := s.square(c).(type) {
+	case filledOutSquare:
+		return 0
+	case emptySquare:
+		return s.shape().allValues &^ sq.eliminatedValues
+	}
+	return s.shape().allValues
+}
+
+// value returns the value of the square at c, zero if it is empty.
+func (s *Sudoku) value(c coordinate) uint8 {
+	if fos, ok := s.square(c).(filledOutSquare); ok {
+		return uint8(fos)
+	}
+	return 0
+}
+
+// eliminate removes sv from the possible values of the square at c, if it is
+// empty. In contrast to emptySquare.eliminated, the square stays empty even if
+// there is only one value left.
+func (s *Sudoku) eliminate(c coordinate, sv uint8) {
+	if s.value(c) != 0 {
+		return
+	}
+	es, _ := s.cells[c].(emptySquare)
+	if es.isValuePossible(sv) {
+		es.eliminatedValues |= 1 << sv
+		es.numberOfEliminatedValues++
+	}
+	s.cells[c] = es
+}
+
+// place fills in the square at c and eliminates sv from all peers, but
+// doesn't propagate any further.
+func (s *Sudoku) place(c coordinate, sv uint8) error {
+	s.cells[c] = filledOutSquare(sv)
+	for _, peerC := range s.layout.peers[c] {
+		if s.value(peerC) == sv {
+			return ErrConflict
+		}
+		s.eliminate(peerC, sv)
+	}
+	return nil
+}
+
+func (s *Sudoku) isFilledOut() bool {
+	for c := range s.cells {
+		if s.value(coordinate(c)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// hasContradiction reports whether there is an empty square without any
+// possible value, or a value that has no place left in a unit.
+func (s *Sudoku) hasContradiction() bool {
+	for c := range s.cells {
+		if s.value(coordinate(c)) == 0 && s.candidates(coordinate(c)) == 0 {
+			return true
+		}
+	}
+	allValues := s.layout.allValues
+	for _, unit := range s.layout.units {
+		var seen uint32
+		for _, c := range unit {
+			seen |= s.candidates(c) | 1<<s.value(c)
+		}
+		if seen&allValues != allValues {
+			return true
+		}
+	}
+	return false
+}
+
+// cellsWith returns the squares of unit that can take sv.
+func (s *Sudoku) cellsWith(unit []coordinate, sv uint8) []coordinate {
+	var res []coordinate
+	for _, c := range unit {
+		if s.candidates(c)&(1<<sv) != 0 {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+// valuesOf returns the values in the bitset in ascending order.
+func valuesOf(set uint32) []uint8 {
+	var res []uint8
+	for sv := uint8(1); sv < 32; sv++ {
+		if set&(1<<sv) != 0 {
+			res = append(res, sv)
+		}
+	}
+	return res
+}
+
+// combinations calls fn for every subset of size k of 0..n-1, in
+// lexicographic order, until fn returns true. It reports whether fn returned
+// true.
+func combinations(n, k int, fn func([]int) bool) bool {
+	combo := make([]int, k)
+	var rec func(start, i int) bool
+	rec = func(start, i int) bool {
+		if i == k {
+			return fn(combo)
+		}
+		for x := start; x <= n-(k-i); x++ {
+			combo[i] = x
+			if rec(x+1, i+1) {
+				return true
+			}
+		}
+		return false
+	}
+	return rec(0, 0)
+}
+
+// Techniques
+
+func findHiddenSingle(s *Sudoku) (deduction, bool) {
+	l := s.layout
+	for _, u := range l.unitSearchOrder {
+		for sv := uint8(1); int(sv) <= l.size; sv++ {
+			if cells := s.cellsWith(l.units[u], sv); len(cells) == 1 {
+				return deduction{
+					technique:  HiddenSingle,
+					units:      []int{u},
+					cells:      cells,
+					values:     []uint8{sv},
+					placements: []candidate{{cells[0], sv}},
+				}, true
+			}
+		}
+	}
+	return deduction{}, false
+}
+
+func findNakedSingle(s *Sudoku) (deduction, bool) {
+	for c := range s.cells {
+		cands := s.candidates(coordinate(c))
+		if bits.OnesCount32(cands) != 1 {
+			continue
+		}
+		sv := valuesOf(cands)[0]
+		return deduction{
+			technique:  NakedSingle,
+			cells:      []coordinate{coordinate(c)},
+			values:     []uint8{sv},
+			placements: []candidate{{coordinate(c), sv}},
+		}, true
+	}
+	return deduction{}, false
+}
+
+func findPointingPair(s *Sudoku) (deduction, bool) {
+	l := s.layout
+	for box := 2 * l.size; box < 3*l.size; box++ {
+		for sv := uint8(1); int(sv) <= l.size; sv++ {
+			cells := s.cellsWith(l.units[box], sv)
+			if len(cells) < 2 {
+				continue
+			}
+			// kind 0 checks for a common row, 1 for a common column
+			for kind := 0; kind < 2; kind++ {
+				line := l.unitsOf[cells[0]][kind]
+				if !l.allInUnit(cells, kind, line) {
+					continue
+				}
+
+				var elims []candidate
+				for _, c := range s.cellsWith(l.units[line], sv) {
+					if l.unitsOf[c][2] != box {
+						elims = append(elims, candidate{c, sv})
+					}
+				}
+				if len(elims) > 0 {
+					return deduction{
+						technique:    PointingPair,
+						units:        []int{box, line},
+						cells:        cells,
+						values:       []uint8{sv},
+						eliminations: elims,
+					}, true
+				}
+			}
+		}
+	}
+	return deduction{}, false
+}
+
+func findBoxLineReduction(s *Sudoku) (deduction, bool) {
+	l := s.layout
+	for line := 0; line < 2*l.size; line++ {
+		kind := line / l.size
+		for sv := uint8(1); int(sv) <= l.size; sv++ {
+			cells := s.cellsWith(l.units[line], sv)
+			if len(cells) < 2 {
+				continue
+			}
+			box := l.unitsOf[cells[0]][2]
+			if !l.allInUnit(cells, 2, box) {
+				continue
+			}
+
+			var elims []candidate
+			for _, c := range s.cellsWith(l.units[box], sv) {
+				if l.unitsOf[c][kind] != line {
+					elims = append(elims, candidate{c, sv})
+				}
+			}
+			if len(elims) > 0 {
+				return deduction{
+					technique:    BoxLineReduction,
+					units:        []int{line, box},
+					cells:        cells,
+					values:       []uint8{sv},
+					eliminations: elims,
+				}, true
+			}
+		}
+	}
+	return deduction{}, false
+}
+
+// allInUnit reports whether the unit of the given kind (0 = row, 1 = column, 2
+// = box) is u for all cells.
+func (l *layout) allInUnit(cells []coordinate, kind, u int) bool {
+	for _, c := range cells {
+		if l.unitsOf[c][kind] != u {
+			return false
+		}
+	}
+	return true
+}
+
+// nakedSubsetFinder returns a finder for n squares of a unit that together
+// can only take n values. These values can be eliminated from the rest of the
+// unit.
+func nakedSubsetFinder(t Technique, n int) func(s *Sudoku) (deduction, bool) {
+	return func(s *Sudoku) (deduction, bool) {
+		l := s.layout
+		for _, u := range l.unitSearchOrder {
+			var open []coordinate
+			for _, c := range l.units[u] {
+				if k := bits.OnesCount32(s.candidates(c)); k >= 2 && k <= n {
+					open = append(open, c)
+				}
+			}
+
+			var d deduction
+			found := combinations(len(open), n, func(combo []int) bool {
+				var union uint32
+				inSubset := make(map[coordinate]bool)
+				for _, i := range combo {
+					union |= s.candidates(open[i])
+					inSubset[open[i]] = true
+				}
+				if bits.OnesCount32(union) != n {
+					return false
+				}
+
+				var elims []candidate
+				for _, c := range l.units[u] {
+					if inSubset[c] {
+						continue
+					}
+					for _, sv := range valuesOf(s.candidates(c) & union) {
+						elims = append(elims, candidate{c, sv})
+					}
+				}
+				if len(elims) == 0 {
+					return false
+				}
+
+				d = deduction{technique: t, units: []int{u}, values: valuesOf(union), eliminations: elims}
+				for _, i := range combo {
+					d.cells = append(d.cells, open[i])
+				}
+				return true
+			})
+			if found {
+				return d, true
+			}
+		}
+		return deduction{}, false
+	}
+}
+
+// hiddenSubsetFinder returns a finder for n values of a unit that can only go
+// into n squares. All other values can be eliminated from these squares.
+func hiddenSubsetFinder(t Technique, n int) func(s *Sudoku) (deduction, bool) {
+	return func(s *Sudoku) (deduction, bool) {
+		l := s.layout
+		for _, u := range l.unitSearchOrder {
+			var open []uint8
+			positions := make([]uint32, l.size+1)
+			for sv := uint8(1); int(sv) <= l.size; sv++ {
+				for i, c := range l.units[u] {
+					if s.candidates(c)&(1<<sv) != 0 {
+						positions[sv] |= 1 << uint(i)
+					}
+				}
+				if k := bits.OnesCount32(positions[sv]); k >= 1 && k <= n {
+					open = append(open, sv)
+				}
+			}
+
+			var d deduction
+			found := combinations(len(open), n, func(combo []int) bool {
+				var where, subset uint32
+				for _, i := range combo {
+					where |= positions[open[i]]
+					subset |= 1 << open[i]
+				}
+				if bits.OnesCount32(where) != n {
+					return false
+				}
+
+				var cells []coordinate
+				var elims []candidate
+				for i, c := range l.units[u] {
+					if where&(1<<uint(i)) == 0 {
+						continue
+					}
+					cells = append(cells, c)
+					for _, sv := range valuesOf(s.candidates(c) &^ subset) {
+						elims = append(elims, candidate{c, sv})
+					}
+				}
+				if len(elims) == 0 {
+					return false
+				}
+
+				d = deduction{technique: t, units: []int{u}, cells: cells, values: valuesOf(subset), eliminations: elims}
+				return true
+			})
+			if found {
+				return d, true
+			}
+		}
+		return deduction{}, false
+	}
+}
+
+// fishFinder returns a finder for n rows in which a value is restricted to
+// the same n columns (or the other way around). The value can then be
+// eliminated from the rest of these columns.
+func fishFinder(t Technique, n int) func(s *Sudoku) (deduction, bool) {
+	return func(s *Sudoku) (deduction, bool) {
+		l := s.layout
+		for sv := uint8(1); int(sv) <= l.size; sv++ {
+			// base 0 uses rows as base lines and columns as cover lines, base
+			// size the other way around
+			for _, base := range []int{0, l.size} {
+				cover := l.size - base
+
+				var open []int
+				positions := make([]uint32, l.size)
+				for line := 0; line < l.size; line++ {
+					for i, c := range l.units[base+line] {
+						if s.candidates(c)&(1<<sv) != 0 {
+							positions[line] |= 1 << uint(i)
+						}
+					}
+					if k := bits.OnesCount32(positions[line]); k >= 2 && k <= n {
+						open = append(open, line)
+					}
+				}
+
+				var d deduction
+				found := combinations(len(open), n, func(combo []int) bool {
+					var where, lines uint32
+					for _, i := range combo {
+						where |= positions[open[i]]
+						lines |= 1 << uint(open[i])
+					}
+					if bits.OnesCount32(where) != n {
+						return false
+					}
+
+					var elims []candidate
+					for p := 0; p < l.size; p++ {
+						if where&(1<<uint(p)) == 0 {
+							continue
+						}
+						for j, c := range l.units[cover+p] {
+							if lines&(1<<uint(j)) == 0 && s.candidates(c)&(1<<sv) != 0 {
+								elims = append(elims, candidate{c, sv})
+							}
+						}
+					}
+					if len(elims) == 0 {
+						return false
+					}
+
+					d = deduction{technique: t, values: []uint8{sv}, eliminations: elims}
+					for _, i := range combo {
+						d.units = append(d.units, base+open[i])
+						for _, c := range l.units[base+open[i]] {
+							if s.candidates(c)&(1<<sv) != 0 {
+								d.cells = append(d.cells, c)
+							}
+						}
+					}
+					return true
+				})
+				if found {
+					return d, true
+				}
+			}
+		}
+		return deduction{}, false
+	}
+}
+
+// wingFinder looks for a pivot with pivotSize values and two pincers with two
+// values each, so that one value z is in both pincers and can be eliminated
+// from every square that sees all of them. For the XY-Wing the pivot doesn't
+// contain z and doesn't need to be seen, for the XYZ-Wing it does.
+func wingFinder(t Technique, pivotSize int) func(s *Sudoku) (deduction, bool) {
+	return func(s *Sudoku) (deduction, bool) {
+		l := s.layout
+		for pivot := range s.cells {
+			pc := s.candidates(coordinate(pivot))
+			if bits.OnesCount32(pc) != pivotSize {
+				continue
+			}
+
+			for _, a := range l.peers[pivot] {
+				ac := s.candidates(a)
+				if bits.OnesCount32(ac) != 2 || bits.OnesCount32(ac&pc) != pivotSize-1 {
+					continue
+				}
+				z := ac &^ pc
+				if pivotSize == 3 {
+					z = ac
+				}
+
+				for _, b := range l.peers[pivot] {
+					bc := s.candidates(b)
+					if b == a || bits.OnesCount32(bc) != 2 || bc == ac {
+						continue
+					}
+					if pivotSize == 2 && bc != (pc&^ac)|z {
+						continue
+					}
+					if pivotSize == 3 {
+						if bc|ac != pc {
+							continue
+						}
+						z = ac & bc
+					}
+
+					sv := valuesOf(z)[0]
+					var elims []candidate
+					for c := range s.cells {
+						cc := coordinate(c)
+						if cc == a || cc == b || cc == coordinate(pivot) || s.candidates(cc)&z == 0 {
+							continue
+						}
+						if !l.isPeer[cc][a] || !l.isPeer[cc][b] {
+							continue
+						}
+						if pivotSize == 3 && !l.isPeer[cc][pivot] {
+							continue
+						}
+						elims = append(elims, candidate{cc, sv})
+					}
+					if len(elims) > 0 {
+						return deduction{
+							technique:    t,
+							cells:        []coordinate{coordinate(pivot), a, b},
+							values:       valuesOf(pc | ac | bc),
+							eliminations: elims,
+						}, true
+					}
+				}
+			}
+		}
+		return deduction{}, false
+	}
+}
+
+var (
+	findXYWing  = wingFinder(XYWing, 2)
+	findXYZWing = wingFinder(XYZWing, 3)
+)
+
+// findSimpleColouring looks at the chains formed by squares that are the only
+// two places for a value in some unit. Exactly one of the two colours in such
+// a chain holds the value. If two squares of the same colour see each other,
+// that colour is wrong. Any square that sees both colours can't take the
+// value either.
+func findSimpleColouring(s *Sudoku) (deduction, bool) {
+	l := s.layout
+	for sv := uint8(1); int(sv) <= l.size; sv++ {
+		links := make(map[coordinate][]coordinate)
+		for _, u := range l.unitSearchOrder {
+			if cells := s.cellsWith(l.units[u], sv); len(cells) == 2 {
+				links[cells[0]] = append(links[cells[0]], cells[1])
+				links[cells[1]] = append(links[cells[1]], cells[0])
+			}
+		}
+
+		colour := make(map[coordinate]int)
+		for start := range s.cells {
+			if _, ok := links[coordinate(start)]; !ok {
+				continue
+			}
+			if _, done := colour[coordinate(start)]; done {
+				continue
+			}
+
+			// colour the chain starting here, alternating between 1 and 2
+			chain := []coordinate{coordinate(start)}
+			colour[coordinate(start)] = 1
+			for i := 0; i < len(chain); i++ {
+				for _, next := range links[chain[i]] {
+					if _, done := colour[next]; !done {
+						colour[next] = 3 - colour[chain[i]]
+						chain = append(chain, next)
+					}
+				}
+			}
+			if len(chain) < 3 {
+				continue
+			}
+
+			if d, ok := colourWrap(l, sv, chain, colour); ok {
+				return d, true
+			}
+			if d, ok := colourTrap(s, sv, chain, colour); ok {
+				return d, true
+			}
+		}
+	}
+	return deduction{}, false
+}
+
+func colourWrap(l *layout, sv uint8, chain []coordinate, colour map[coordinate]int) (deduction, bool) {
+	for i, a := range chain {
+		for _, b := range chain[i+1:] {
+			if colour[a] != colour[b] || !l.isPeer[a][b] {
+				continue
+			}
+
+			var elims []candidate
+			for _, c := range chain {
+				if colour[c] == colour[a] {
+					elims = append(elims, candidate{c, sv})
+				}
+			}
+			return deduction{technique: SimpleColouring, cells: chain, values: []uint8{sv}, eliminations: elims}, true
+		}
+	}
+	return deduction{}, false
+}
+
+func colourTrap(s *Sudoku, sv uint8, chain []coordinate, colour map[coordinate]int) (deduction, bool) {
+	inChain := make(map[coordinate]bool)
+	for _, c := range chain {
+		inChain[c] = true
+	}
+
+	var elims []candidate
+	for c := range s.cells {
+		cc := coordinate(c)
+		if inChain[cc] || s.candidates(cc)&(1<<sv) == 0 {
+			continue
+		}
+
+		var sees [3]bool
+		for _, x := range chain {
+			if s.layout.isPeer[cc][x] {
+				sees[colour[x]] = true
+			}
+		}
+		if sees[1] && sees[2] {
+			elims = append(elims, candidate{cc, sv})
+		}
+	}
+	if len(elims) == 0 {
+		return deduction{}, false
+	}
+	return deduction{technique: SimpleColouring, cells: chain, values: []uint8{sv}, eliminations: elims}, true
+}
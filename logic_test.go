@@ -0,0 +1,223 @@
+package sudoku
+
+import (
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// checkDeductionsIn verifies every deduction made for the sudokus in the
+// given fixture against the solution found by search. It returns how often
+// each technique was used.
+func checkDeductionsIn(filename string, t *testing.T) map[Technique]int {
+	contents, err := ioutil.ReadFile(filepath.Join("fixtures", filename))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := strings.NewReader(string(contents))
+	used := make(map[Technique]int)
+	for {
+		sudoku, err := ParseReader(rr)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		solution, err := sudoku.Solve()
+		if err != nil {
+			t.Fatal(err)
+		}
+		cells := solution.AsInts()
+
+		_, steps, err := sudoku.deduceLogically()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, d := range steps {
+			used[d.technique]++
+			for _, p := range d.placements {
+				if expected := cells[p.c/9][p.c%9]; expected != p.sv {
+					t.Errorf("%v placed %v, but solution has %v", d.technique, p, expected)
+				}
+			}
+			for _, e := range d.eliminations {
+				if expected := cells[e.c/9][e.c%9]; expected == e.sv {
+					t.Errorf("%v eliminated %v, which is in the solution", d.technique, e)
+				}
+			}
+		}
+	}
+	return used
+}
+
+func TestDeductionsAreCorrect(t *testing.T) {
+	used := checkDeductionsIn("hardest.txt", t)
+	if !testing.Short() {
+		for technique, n := range checkDeductionsIn("top95.txt", t) {
+			used[technique] += n
+		}
+	}
+	t.Log(used)
+
+	for _, technique := range []Technique{HiddenSingle, NakedSingle, PointingPair, BoxLineReduction, NakedPair} {
+		if used[technique] == 0 {
+			t.Error("Expected", technique, "to be used")
+		}
+	}
+}
+
+func TestSolveLogicallyEasy(t *testing.T) {
+	s, err := Parse("003020600900305001001806400008102900700000008006708200002609500800203009005010300")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	solved, ok, err := s.SolveLogically()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatal("Expected to solve an easy sudoku logically")
+	}
+	assertIsValidSudoku(solved, t)
+}
+
+func TestSolveLogicallyGetsStuck(t *testing.T) {
+	s, err := Parse("..53.....8......2..7..1.5..4....53...1..7...6..32...8..6.5....9..4....3......97..")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	partial, ok, err := s.SolveLogically()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok {
+		t.Fatal("Expected the hardest sudoku to need guessing")
+	}
+
+	solution, err := s.Solve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the partial solution has to lead to the same solution
+	solvedPartial, err := partial.Solve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if solvedPartial.AsInts() != solution.AsInts() {
+		t.Error("Expected\n", solution, "but got\n", solvedPartial)
+	}
+}
+
+func TestSolveLogicallyOnlyUsesClues(t *testing.T) {
+	s, err := Parse("003020600900305001001806400008102900700000008006708200002609500800203009005010300")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := s.cluesOnly()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clues := 0
+	for c := range g.cells {
+		if g.value(coordinate(c)) != 0 {
+			clues++
+		}
+	}
+	if clues != 32 {
+		t.Error("Expected 32 clues, but got", clues)
+	}
+}
+
+// candidateGrid returns an empty sudoku in which the given squares can only
+// take the given values.
+func candidateGrid(restrictions map[string][]uint8) Sudoku {
+	g, _ := (Sudoku{}).cluesOnly()
+	for name, values := range restrictions {
+		c := coord(rune(name[0]), rune(name[1]))
+		for sv := uint8(1); sv <= 9; sv++ {
+			allowed := false
+			for _, v := range values {
+				allowed = allowed || v == sv
+			}
+			if !allowed {
+				g.eliminate(c, sv)
+			}
+		}
+	}
+	return g
+}
+
+func assertEliminates(t *testing.T, d deduction, ok bool, expected ...candidate) {
+	if !ok {
+		t.Fatal("Expected to find a deduction")
+	}
+	if len(d.eliminations) != len(expected) {
+		t.Fatal("Expected eliminations", expected, "but got", d.eliminations)
+	}
+	for i := range expected {
+		if d.eliminations[i] != expected[i] {
+			t.Error("Expected eliminations", expected, "but got", d.eliminations)
+		}
+	}
+}
+
+func TestFindXYWing(t *testing.T) {
+	g := candidateGrid(map[string][]uint8{
+		"A1": {1, 2},
+		"A5": {1, 3},
+		"D1": {2, 3},
+	})
+
+	d, ok := findXYWing(&g)
+	assertEliminates(t, d, ok, candidate{coord('D', '5'), 3})
+}
+
+func TestFindXYZWing(t *testing.T) {
+	g := candidateGrid(map[string][]uint8{
+		"A1": {1, 2, 3},
+		"A5": {1, 3},
+		"B2": {2, 3},
+	})
+
+	d, ok := findXYZWing(&g)
+	assertEliminates(t, d, ok, candidate{coord('A', '2'), 3}, candidate{coord('A', '3'), 3})
+}
+
+func TestFindSimpleColouring(t *testing.T) {
+	g := candidateGrid(nil)
+	// chain A1 - A5 (row A), A5 - C4 (box 2), C4 - H4 (column 4)
+	for _, c := range layouts[3].units[0] {
+		if c != coord('A', '1') && c != coord('A', '5') {
+			g.eliminate(c, 1)
+		}
+	}
+	for _, c := range layouts[3].units[19] {
+		if c != coord('A', '5') && c != coord('C', '4') {
+			g.eliminate(c, 1)
+		}
+	}
+	for _, c := range layouts[3].units[12] {
+		if c != coord('C', '4') && c != coord('H', '4') {
+			g.eliminate(c, 1)
+		}
+	}
+
+	d, ok := findSimpleColouring(&g)
+	assertEliminates(t, d, ok, candidate{coord('H', '1'), 1})
+}
+
+func TestTechniqueString(t *testing.T) {
+	if s := XYZWing.String(); s != "XYZ-Wing" {
+		t.Error("Unexpected name", s)
+	}
+	if s := Technique(200).String(); s != "Technique(200)" {
+		t.Error("Unexpected name", s)
+	}
+}
@@ -0,0 +1,64 @@
+package sudoku
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+var (
+	// ErrNotUnique is returned when a sudoku has more than one solution, but
+	// a unique one is required.
+	ErrNotUnique = fmt.Errorf("Multiple solutions")
+)
+
+// MinimizeOptions controls how clues are removed by Minimize.
+type MinimizeOptions struct {
+	// Symmetry is preserved by removing only whole groups of clues that are
+	// mapped onto each other, see Symmetry.
+	Symmetry Symmetry
+	// Shuffle removes the clues in an order chosen randomly from Seed.
+	// Otherwise the clues are removed in order, from A1 to I9.
+	Shuffle bool
+	Seed    int64
+}
+
+// Minimize removes clues from the receiver one at a time, as long as the
+// solution stays unique, until no more clue can be removed. Both orders lead
+// to minimal sudokus, but usually different ones. If a symmetry is given,
+// the result is minimal in the sense that no more group of clues can be
+// removed.
+//
+// The receiver needs to have exactly one solution, otherwise an error is
+// returned. The returned sudoku contains only the remaining clues.
+func (s Sudoku) Minimize(opts MinimizeOptions) (Sudoku, error) {
+	l := s.shape()
+	blank, err := s.blank()
+	if err != nil {
+		return s, err
+	}
+	start, err := withClues(blank, s.clues)
+	if err != nil {
+		return s, err
+	}
+	switch start.CountSolutions(2) {
+	case 0:
+		return s, ErrConflict
+	case 2:
+		return s, ErrNotUnique
+	}
+
+	groups := opts.Symmetry.groups(l)
+	if opts.Shuffle {
+		rnd := rand.New(rand.NewSource(opts.Seed))
+		rnd.Shuffle(len(groups), func(i, j int) {
+			groups[i], groups[j] = groups[j], groups[i]
+		})
+	}
+
+	clues := removeClues(blank, start.clues, groups, Sudoku.IsUnique)
+	minimal, err := withClues(blank, clues)
+	if err != nil {
+		return s, err
+	}
+	return minimal.cluesOnly()
+}
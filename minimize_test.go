@@ -0,0 +1,91 @@
+package sudoku
+
+import (
+	"testing"
+)
+
+// assertIsMinimal checks that each clue of s is essential for a unique
+// solution, and returns the number of clues.
+func assertIsMinimal(t *testing.T, s Sudoku) int {
+	if !s.IsUnique() {
+		t.Fatal("Expected a unique solution for\n", s)
+	}
+
+	blank, err := s.blank()
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	for c, sv := range s.clues {
+		if sv == 0 {
+			continue
+		}
+		count++
+
+		reduced := append([]uint8(nil), s.clues...)
+		reduced[c] = 0
+		if r, err := withClues(blank, reduced); err == nil && r.IsUnique() {
+			t.Error("Expected", coordinate(c), "to be essential in\n", s)
+		}
+	}
+	return count
+}
+
+func TestMinimize(t *testing.T) {
+	// a complete solution
+	s, err := Parse("859612437723854169164379528986147352375268914241593786432981675617425893598736241")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	minimal, err := s.Minimize(MinimizeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := assertIsMinimal(t, minimal)
+	if n >= 81 || n < 17 {
+		t.Error("Unexpected number of clues", n)
+	}
+
+	solution, err := minimal.Solve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if solution.AsInts() != s.AsInts() {
+		t.Error("Expected the same solution, but got\n", solution)
+	}
+
+	again, err := s.Minimize(MinimizeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.String() != minimal.String() {
+		t.Error("Expected the deterministic order to give the same result")
+	}
+}
+
+func TestMinimizeShuffledWithSymmetry(t *testing.T) {
+	s, err := Parse("859612437723854169164379528986147352375268914241593786432981675617425893598736241")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	minimal, err := s.Minimize(MinimizeOptions{Symmetry: Rotational, Shuffle: true, Seed: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !minimal.IsUnique() {
+		t.Fatal("Expected a unique solution")
+	}
+	assertHasSymmetry(t, minimal, Rotational)
+}
+
+func TestMinimizeRejectsAmbiguous(t *testing.T) {
+	s, err := Parse("85...24...2......9..4.........1.7..23.5...9...4...........8..7..17..........36.4.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Minimize(MinimizeOptions{}); err != ErrNotUnique {
+		t.Error("Expected ErrNotUnique, but got", err)
+	}
+}
@@ -37,6 +37,7 @@ func testAllIn(filename string, t *testing.T) {
 	rr := strings.NewReader(string(contents))
 
 	var times []time.Duration
+	var guesses, backtracks int
 
 	for {
 		timeStart := time.Now()
@@ -47,8 +48,10 @@ func testAllIn(filename string, t *testing.T) {
 		if err != nil {
 			t.Error(err)
 		}
-		solution, err := sudoku.Solve()
+		solution, stats, err := sudoku.SolveWithStats()
 		timeEnd := time.Now()
+		guesses += stats.Guesses
+		backtracks += stats.Backtracks
 
 		if err != nil {
 			t.Error(err)
@@ -82,7 +85,7 @@ func testAllIn(filename string, t *testing.T) {
 		median = times[count/2]
 	}
 
-	fmt.Printf("%s: min=%v max=%v avg=%v median=%v (%v sudokus in %v)\n", filename, time.Duration(min), time.Duration(max), median, time.Duration(sum/count), count, time.Duration(sum))
+	fmt.Printf("%s: min=%v max=%v avg=%v median=%v (%v sudokus in %v, %v guesses, %v backtracks)\n", filename, time.Duration(min), time.Duration(max), median, time.Duration(sum/count), count, time.Duration(sum), guesses, backtracks)
 }
 
 func TestEasy(t *testing.T) {
@@ -0,0 +1,138 @@
+package sudoku
+
+import (
+	"fmt"
+	"math"
+)
+
+// Rating
+
+// A Tier is a named class of difficulty.
+type Tier uint8
+
+const (
+	// Easy sudokus can be solved with singles only.
+	Easy Tier = iota
+	// Medium sudokus additionally need interactions between boxes and lines.
+	Medium
+	// Hard sudokus need subsets, X-Wings or Swordfish.
+	Hard
+	// Expert sudokus need wings, colouring or the largest subsets and fish.
+	Expert
+	// Extreme sudokus can't be solved without guessing.
+	Extreme
+)
+
+var tierNames = [...]string{
+	Easy:    "Easy",
+	Medium:  "Medium",
+	Hard:    "Hard",
+	Expert:  "Expert",
+	Extreme: "Extreme",
+}
+
+func (t Tier) String() string {
+	if int(t) < len(tierNames) {
+		return tierNames[t]
+	}
+	return fmt.Sprintf("Tier(%d)", uint8(t))
+}
+
+// tierLimits holds the lowest score of every tier but the first.
+var tierLimits = [...]float64{
+	Medium:  2.6,
+	Hard:    3.0,
+	Expert:  4.2,
+	Extreme: guessingWeight,
+}
+
+// techniqueWeights rates each technique, loosely following the ratings of
+// Sudoku Explainer.
+var techniqueWeights = [...]float64{
+	HiddenSingle:     1.5,
+	NakedSingle:      2.3,
+	PointingPair:     2.6,
+	BoxLineReduction: 2.8,
+	NakedPair:        3.0,
+	XWing:            3.2,
+	HiddenPair:       3.4,
+	NakedTriple:      3.6,
+	Swordfish:        3.8,
+	HiddenTriple:     4.0,
+	XYWing:           4.2,
+	XYZWing:          4.4,
+	SimpleColouring:  4.6,
+	NakedQuad:        5.0,
+	Jellyfish:        5.2,
+	HiddenQuad:       5.4,
+}
+
+// guessingWeight is the weight used for sudokus that need guessing.
+const guessingWeight = 10.0
+
+// A Rating describes how hard a sudoku is for a human.
+type Rating struct {
+	// Score is the weight of the hardest technique needed (between 1.5 and
+	// 5.4, or 10 if guessing is needed), plus 0.01 for every step that isn't
+	// a single, up to 0.09. So the hardest technique always dominates, and
+	// the number of steps decides between sudokus that need the same one.
+	Score float64
+	Tier  Tier
+	// Hardest is the hardest technique used. It is meaningless if
+	// NeedsGuessing is set.
+	Hardest Technique
+	// Steps is the number of steps taken by the logical solver.
+	Steps int
+	// NeedsGuessing is set if the techniques are not sufficient to solve the
+	// sudoku.
+	NeedsGuessing bool
+}
+
+func (r Rating) String() string {
+	if r.NeedsGuessing {
+		return fmt.Sprintf("%.2f %v (needs guessing after %d steps)", r.Score, r.Tier, r.Steps)
+	}
+	return fmt.Sprintf("%.2f %v (%v, %d steps)", r.Score, r.Tier, r.Hardest, r.Steps)
+}
+
+// Rate rates the difficulty of solving the clues of the receiver. The rating
+// only depends on the clues, so it is stable across runs and machines. If
+// the sudoku has no solution, an error is returned.
+func (s Sudoku) Rate() (Rating, error) {
+	g, steps, err := s.deduceLogically()
+	if err != nil {
+		return Rating{}, err
+	}
+
+	var r Rating
+	bonus := 0
+	for _, d := range steps {
+		if d.technique > r.Hardest {
+			r.Hardest = d.technique
+		}
+		if d.technique != HiddenSingle && d.technique != NakedSingle {
+			bonus++
+		}
+	}
+	r.Steps = len(steps)
+
+	weight := techniqueWeights[r.Hardest]
+	if !g.isFilledOut() {
+		if _, err := g.Solve(); err != nil {
+			return Rating{}, err
+		}
+		r.NeedsGuessing = true
+		weight = guessingWeight
+	}
+	if bonus > 9 {
+		bonus = 9
+	}
+	r.Score = math.Round((weight+float64(bonus)/100)*100) / 100
+
+	for t := Medium; t <= Extreme; t++ {
+		if r.Score >= tierLimits[t] {
+			r.Tier = t
+		}
+	}
+	return r, nil
+}
@@ -0,0 +1,56 @@
+package sudoku
+
+import (
+	"testing"
+)
+
+func TestRate(t *testing.T) {
+	cases := []struct {
+		source   string
+		expected Rating
+	}{
+		{
+			"003020600900305001001806400008102900700000008006708200002609500800203009005010300",
+			Rating{Score: 1.5, Tier: Easy, Hardest: HiddenSingle, Steps: 49},
+		},
+		{
+			"85...24..72......9..4.........1.7..23.5...9...4...........8..7..17..........36.4.",
+			Rating{Score: 2.68, Tier: Medium, Hardest: PointingPair, Steps: 67},
+		},
+		{
+			"..53.....8......2..7..1.5..4....53...1..7...6..32...8..6.5....9..4....3......97..",
+			Rating{Score: 10.02, Tier: Extreme, Hardest: SimpleColouring, Steps: 4, NeedsGuessing: true},
+		},
+	}
+
+	for _, c := range cases {
+		s, err := Parse(c.source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rating, err := s.Rate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rating != c.expected {
+			t.Errorf("Expected %+v, but got %+v", c.expected, rating)
+		}
+	}
+}
+
+func TestRateUnsolvable(t *testing.T) {
+	s, err := Parse("851..24..72......9..4.........1.7..23.5...9...4...........8..7..17..........36.4.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Rate(); err == nil {
+		t.Error("Expected an error for a sudoku without solution")
+	}
+}
+
+func TestRatingString(t *testing.T) {
+	r := Rating{Score: 4.23, Tier: Expert, Hardest: XYWing, Steps: 57}
+	if actual := r.String(); actual != "4.23 Expert (XY-Wing, 57 steps)" {
+		t.Error("Unexpected string", actual)
+	}
+}
@@ -0,0 +1,274 @@
+package sudoku
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Relational constraints
+
+var (
+	// ErrInvalidMarkers is returned by Markers if the markers can't be read.
+	ErrInvalidMarkers = fmt.Errorf("Invalid markers")
+)
+
+// A relation tells whether the values a and b can be in two related squares.
+type relation func(a, b uint8) bool
+
+func consecutive(a, b uint8) bool {
+	return a+1 == b || b+1 == a
+}
+
+func nonConsecutive(a, b uint8) bool {
+	return !consecutive(a, b)
+}
+
+func double(a, b uint8) bool {
+	return a == 2*b || b == 2*a
+}
+
+func equal(a, b uint8) bool {
+	return a == b
+}
+
+// farApart returns the relation of values that differ by at least distance.
+func farApart(distance uint8) relation {
+	return func(a, b uint8) bool {
+		return a >= b+distance || b >= a+distance
+	}
+}
+
+func sumsTo(sum uint8) relation {
+	return func(a, b uint8) bool {
+		return a+b == sum
+	}
+}
+
+// possible returns the values still possible in the square at c as a bitset,
+// including the value of a filled square.
+func (p propagator) possible(c coordinate) uint32 {
+	if v := p.s.value(c); v != 0 {
+		return 1 << v
+	}
+	return p.s.candidates(c)
+}
+
+// relate eliminates the values of a and b that can't be paired with any of
+// the values possible in the other square.
+func (p propagator) relate(a, b coordinate, rel relation) error {
+	if err := p.support(a, b, rel); err != nil {
+		return err
+	}
+	return p.support(b, a, func(x, y uint8) bool {
+		return rel(y, x)
+	})
+}
+
+// support eliminates the values of b for which there is no value x possible
+// in a such that rel(x, b) holds, and x differs from b if the squares are
+// peers. If b is filled in with such a value, it returns ErrConflict.
+func (p propagator) support(a, b coordinate, rel relation) error {
+	from := valuesOf(p.possible(a))
+	peers := p.s.layout.isPeer[a][b]
+	for _, y := range valuesOf(p.possible(b)) {
+		supported := false
+		for _, x := range from {
+			if rel(x, y) && !(peers && x == y) {
+				supported = true
+				break
+			}
+		}
+		if supported {
+			continue
+		}
+		if err := p.eliminate(b, y); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// A position is a square given by row and column, so that it can be placed
+// on boards of any size.
+type position struct {
+	row, col int
+}
+
+// parsePosition reads a square in the notation of layout.name, as in A1 or
+// P16.
+func parsePosition(name string) (position, bool) {
+	if len(name) < 2 {
+		return position{}, false
+	}
+	row := unicode.ToUpper(rune(name[0])) - 'A'
+	col, err := strconv.Atoi(name[1:])
+	if row < 0 || row >= 26 || err != nil || col < 1 {
+		return position{}, false
+	}
+	return position{int(row), col - 1}, true
+}
+
+// in returns the coordinate of the position in l, and whether it is on the
+// board.
+func (pos position) in(l *layout) (coordinate, bool) {
+	if pos.row >= l.size || pos.col >= l.size {
+		return 0, false
+	}
+	return l.coord(pos.row, pos.col), true
+}
+
+func (pos position) String() string {
+	return fmt.Sprintf("%c%d", 'A'+pos.row, pos.col+1)
+}
+
+// adjacent reports whether both positions share an edge.
+func (pos position) adjacent(other position) bool {
+	dr, dc := pos.row-other.row, pos.col-other.col
+	return dr*dr+dc*dc == 1
+}
+
+// relational provides the methods of constraints that relate pairs of squares
+// without adding units or peers.
+type relational struct{}
+
+func (relational) units(l *layout) [][]coordinate {
+	return nil
+}
+
+func (relational) peers(l *layout, c coordinate) []coordinate {
+	return nil
+}
+
+// NonConsecutiveNeighbours forbids consecutive values in orthogonally
+// adjacent squares, see NonConsecutive.
+var NonConsecutiveNeighbours Constraint = neighbours{}
+
+type neighbours struct {
+	relational
+}
+
+// of returns the squares orthogonally adjacent to c.
+func (neighbours) of(l *layout, c coordinate) []coordinate {
+	var res []coordinate
+	row, col := int(c)/l.size, int(c)%l.size
+	for _, n := range [][2]int{{row - 1, col}, {row + 1, col}, {row, col - 1}, {row, col + 1}} {
+		if n[0] >= 0 && n[0] < l.size && n[1] >= 0 && n[1] < l.size {
+			res = append(res, l.coord(n[0], n[1]))
+		}
+	}
+	return res
+}
+
+func (n neighbours) restrict(p propagator) error {
+	return nil
+}
+
+func (n neighbours) propagate(p propagator, c coordinate, sv uint8) error {
+	for _, other := range n.of(p.s.layout, c) {
+		if err := p.support(c, other, nonConsecutive); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// The kinds of markers read by Markers, and the relations they stand for.
+var markerRelations = map[byte]relation{
+	'W': consecutive,
+	'B': double,
+	'X': sumsTo(10),
+	'V': sumsTo(5),
+}
+
+// A dot relates the values of two adjacent squares.
+type dot struct {
+	a, b position
+	kind byte
+}
+
+// Markers returns a constraint for markers between adjacent squares, as in
+// Kropki and XV sudokus. Every marker is given as the two squares it stands
+// between followed by its kind, for example
+//
+//	A1-A2=W C4-D4=B E5-E6=X
+//
+// The kinds are
+//
+//	W  a white dot, the values are consecutive
+//	B  a black dot, one value is double the other
+//	X  the values add up to 10
+//	V  the values add up to 5
+//
+// Markers are separated by whitespace, lines starting with '#' are comments.
+// If a marker can't be read or its squares aren't orthogonally adjacent,
+// ErrInvalidMarkers is returned. There are no rules for squares without
+// marker.
+//
+// When the sudoku is printed, the markers are added below the grid in the
+// same format.
+func Markers(markers string) (Constraint, error) {
+	var res markerSet
+	for _, line := range strings.Split(markers, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		for _, field := range strings.Fields(line) {
+			i, j := strings.IndexRune(field, '-'), strings.IndexRune(field, '=')
+			if i < 0 || j < i || len(field) != j+2 {
+				return nil, ErrInvalidMarkers
+			}
+			a, okA := parsePosition(field[:i])
+			b, okB := parsePosition(field[i+1 : j])
+			kind := byte(unicode.ToUpper(rune(field[j+1])))
+			if _, ok := markerRelations[kind]; !ok || !okA || !okB || !a.adjacent(b) {
+				return nil, ErrInvalidMarkers
+			}
+			res.dots = append(res.dots, dot{a, b, kind})
+		}
+	}
+	return res, nil
+}
+
+type markerSet struct {
+	relational
+	dots []dot
+}
+
+func (m markerSet) restrict(p propagator) error {
+	for _, d := range m.dots {
+		a, okA := d.a.in(p.s.layout)
+		b, okB := d.b.in(p.s.layout)
+		if !okA || !okB {
+			return ErrInvalidConstraints
+		}
+		if err := p.relate(a, b, markerRelations[d.kind]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m markerSet) propagate(p propagator, c coordinate, sv uint8) error {
+	l := p.s.layout
+	for _, d := range m.dots {
+		a, _ := d.a.in(l)
+		b, _ := d.b.in(l)
+		if a == c || b == c {
+			if err := p.relate(a, b, markerRelations[d.kind]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// appendix describes the markers in the format read by Markers.
+func (m markerSet) appendix(l *layout) string {
+	fields := make([]string, len(m.dots))
+	for i, d := range m.dots {
+		fields[i] = fmt.Sprintf("%v-%v=%c", d.a, d.b, d.kind)
+	}
+	return strings.Join(fields, " ") + "\n"
+}
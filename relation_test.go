@@ -0,0 +1,130 @@
+package sudoku
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const (
+	kropkiDots = `# every dot of the solution
+A3-A4=B A3-B3=B A4-B4=B A7-A8=W A8-A9=W A9-B9=W B1-B2=W B2-C2=W B3-B4=B
+B6-C6=W B8-C8=W B9-C9=B C1-D1=W C3-D3=W C4-C5=W C5-C6=W C5-D5=W C8-C9=B
+C9-D9=W D1-E1=W D2-D3=W D3-D4=W D4-D5=B D7-D8=W D7-E7=W D8-E8=W E1-F1=W
+E2-E3=W E4-E5=W E5-E6=B E9-F9=W F1-F2=B F4-G4=W F5-F6=W F5-G5=B F6-G6=W
+F7-G7=W F8-F9=B F8-G8=W G1-H1=W G3-G4=B G5-G6=B G5-H5=W H1-H2=B H2-H3=W
+H3-I3=W H6-I6=W H7-H8=W H7-I7=B H8-H9=W I1-I2=W I3-I4=W I6-I7=B I7-I8=W`
+
+	xvMarkers = `A2-A3=X A4-A5=V A5-B5=X B1-C1=X B2-B3=X B3-C3=V B4-C4=X C8-D8=X D1-D2=V
+D3-D4=V E5-F5=X E6-E7=X E9-F9=V F2-G2=X F7-F8=V G2-G3=X G4-G5=X G6-G7=X
+G7-H7=X H1-I1=X H8-I8=X`
+	xvSudoku = "........7.....5.............................................2.....9...........4.."
+
+	markersSolution = "928413567764295318351876924412369785587124693639587142173648259245931876896752431"
+)
+
+func TestMarkers(t *testing.T) {
+	cases := []struct {
+		name, markers, sudoku string
+	}{
+		{"Kropki", kropkiDots, strings.Repeat(".", 81)},
+		{"XV", xvMarkers, xvSudoku},
+	}
+	expected, _ := Parse(markersSolution)
+	for _, c := range cases {
+		opts := ParseOptions{Markers: c.markers}
+		s, err := opts.Parse(c.sudoku)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !s.IsUnique() {
+			t.Error(c.name, ": expected a unique solution")
+		}
+
+		solved, err := s.Solve()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if solved.AsInts() != expected.AsInts() {
+			t.Error(c.name, ": expected\n", expected, "but got\n", solved)
+		}
+
+		str := solved.String()
+		appendix := str[strings.Index(str, "\n\n")+2:]
+		if appendix != strings.Join(strings.Fields(strings.TrimPrefix(c.markers, "# every dot of the solution")), " ")+"\n" {
+			t.Error(c.name, ": expected the markers below the grid, but got\n", str)
+		}
+		again, err := ParseOptions{Markers: appendix}.Parse(str)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if again.String() != str {
+			t.Error(c.name, ": expected the markers to be read again, but got\n", again)
+		}
+	}
+}
+
+func TestMarkersPropagate(t *testing.T) {
+	s, err := ParseOptions{Markers: "A1-A2=B A2-A3=W B1-B2=X C1-C2=V"}.Parse(strings.Repeat(".", 81))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := map[coordinate][]uint8{
+		coord('A', '1'): {1, 2, 3, 4, 6, 8},
+		coord('B', '1'): {1, 2, 3, 4, 6, 7, 8, 9},
+		coord('C', '1'): {1, 2, 3, 4},
+	}
+	for c, expected := range cases {
+		if actual := valuesOf(s.candidates(c)); len(actual) != len(expected) {
+			t.Error("Expected", expected, "in", c, "but got", actual)
+		}
+	}
+
+	if s, err = s.WithCellValued('A', '1', 3); err != nil {
+		t.Fatal(err)
+	}
+	if v := s.AsInts()[0][1]; v != 6 {
+		t.Error("Expected A2 to be 6, but got", v)
+	}
+	if actual := valuesOf(s.candidates(coord('A', '3'))); len(actual) != 2 || actual[0] != 5 || actual[1] != 7 {
+		t.Error("Expected 5 and 7 in A3, but got", actual)
+	}
+	if _, err := s.WithCellValued('A', '3', 8); err != ErrConflict {
+		t.Error("Expected ErrConflict next to a white dot, but got", err)
+	}
+}
+
+func TestMarkersInvalid(t *testing.T) {
+	for _, markers := range []string{"A1-A3=W", "A1-B2=X", "A1A2=W", "A1-A2=Y", "A1-A2=", "A1-A2=WB", "A0-A1=V", "1A-2A=V"} {
+		if _, err := Markers(markers); err != ErrInvalidMarkers {
+			t.Error("Expected ErrInvalidMarkers for", markers, "but got", err)
+		}
+	}
+	if _, err := (ParseOptions{BoxSize: 2, Markers: "I8-I9=X"}).Parse(strings.Repeat(".", 16)); err != ErrInvalidConstraints {
+		t.Error("Expected ErrInvalidConstraints for markers outside the board, but got", err)
+	}
+}
+
+func TestNonConsecutive(t *testing.T) {
+	// the "Miracle Sudoku" by Mitchell Lee, with just two givens
+	miracle := strings.Repeat(".", 38) + "1" + strings.Repeat(".", 12) + "2" + strings.Repeat(".", 29)
+	s, err := ParseOptions{Variant: AntiKnight | AntiKing | NonConsecutive}.Parse(miracle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.IsUnique() {
+		t.Error("Expected a unique solution")
+	}
+	solved, err := s.Solve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := Parse("483726159726159483159483726837261594261594837594837261372615948615948372948372615")
+	if solved.AsInts() != expected.AsInts() {
+		t.Error("Expected\n", expected, "but got\n", solved)
+	}
+
+	if _, err := (ParseOptions{Variant: NonConsecutive}).Parse("12" + strings.Repeat(".", 79)); !errors.Is(err, ErrConflict) {
+		t.Error("Expected ErrConflict for consecutive neighbours, but got", err)
+	}
+}
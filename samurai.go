@@ -0,0 +1,247 @@
+package sudoku
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Samurai sudokus
+
+var (
+	// ErrUnknownGrid is returned by Samurai.Grid for a grid other than
+	// TopLeft to BottomRight.
+	ErrUnknownGrid = fmt.Errorf("Unknown grid")
+)
+
+// A Samurai is a samurai sudoku, five standard sudokus arranged on a 21x21
+// board. The centre sudoku shares each of its corner boxes with one of the
+// other four, which take the corners of the board. Every row, column and box
+// of each of the five sudokus contains every value once.
+//
+// Like a Sudoku, a Samurai is an immutable value. The zero value is an empty
+// samurai sudoku.
+type Samurai struct {
+	s Sudoku
+}
+
+// The grids of a samurai sudoku, as passed to Samurai.Grid.
+const (
+	TopLeft = iota
+	TopRight
+	Centre
+	BottomLeft
+	BottomRight
+)
+
+// samuraiGrids holds the first row and column of every grid on the board.
+var samuraiGrids = [...][2]int{
+	TopLeft:     {0, 0},
+	TopRight:    {0, 12},
+	Centre:      {6, 6},
+	BottomLeft:  {12, 0},
+	BottomRight: {12, 12},
+}
+
+var samuraiGridNames = [...]string{"top left", "top right", "centre", "bottom left", "bottom right"}
+
+// samuraiWidth is the number of rows and columns of the board.
+const samuraiWidth = 21
+
+// samurai is the layout of samurai sudokus. Its coordinates number the
+// squares of the board row by row, leaving out the ones not covered by any
+// grid.
+var samurai = newSamuraiLayout()
+
+// samuraiSquares maps the rows and columns of the board to coordinates, -1
+// for squares not covered by any grid.
+var samuraiSquares [samuraiWidth][samuraiWidth]int
+
+func newSamuraiLayout() *layout {
+	classic, _ := newLayout(3, Classic())
+	l := &layout{
+		box:       3,
+		size:      9,
+		allValues: classic.allValues,
+		digits:    classic.digits,
+	}
+
+	for row := range samuraiSquares {
+		for col := range samuraiSquares[row] {
+			samuraiSquares[row][col] = -1
+			if samuraiBox(row/3, col/3) {
+				samuraiSquares[row][col] = l.numCells
+				l.names = append(l.names, position{row, col}.String())
+				l.numCells++
+			}
+		}
+	}
+	l.unitsOf = make([][]int, l.numCells)
+
+	// the boxes shared by two grids are only added once
+	seen := make(map[coordinate]bool)
+	for g, offset := range samuraiGrids {
+		var grid []coordinate
+		for u, unit := range classic.units {
+			squares := make([]coordinate, len(unit))
+			for i, c := range unit {
+				row, col := offset[0]+int(c)/9, offset[1]+int(c)%9
+				squares[i] = coordinate(samuraiSquares[row][col])
+			}
+			if u < 9 {
+				grid = append(grid, squares...)
+			}
+			if u >= 18 && seen[squares[0]] {
+				continue
+			}
+
+			for _, c := range squares {
+				l.unitsOf[c] = append(l.unitsOf[c], len(l.units))
+			}
+			l.unitSearchOrder = append(l.unitSearchOrder, len(l.units))
+			l.units = append(l.units, squares)
+			l.unitNames = append(l.unitNames, fmt.Sprintf("%s of the %s grid", classic.unitName(u), samuraiGridNames[g]))
+		}
+		for _, c := range grid {
+			seen[c] = true
+		}
+	}
+
+	l.addPeers()
+	return l
+}
+
+// samuraiBox reports whether the box in the given row and column of boxes is
+// covered by any grid.
+func samuraiBox(row, col int) bool {
+	for _, offset := range samuraiGrids {
+		if row >= offset[0]/3 && row < offset[0]/3+3 && col >= offset[1]/3 && col < offset[1]/3+3 {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseSamurai reads a samurai sudoku from the given string, see
+// ParseSamuraiReader.
+func ParseSamurai(s string) (Samurai, error) {
+	return ParseSamuraiReader(strings.NewReader(s))
+}
+
+// ParseSamuraiReader reads a complete samurai sudoku from the given rune
+// reader. The squares of the board are read row by row, leaving out the ones
+// not covered by any grid, which makes 369 squares. Otherwise the same
+// semantics as for ParseReader apply, so that the output of String can be
+// read again. The squares named in a ParseError are counted across the
+// whole board, from A1 to U21.
+func ParseSamuraiReader(rr io.RuneReader) (Samurai, error) {
+	s, err := parseCells(Sudoku{layout: samurai}.clone(), newRuneCounter(rr), false)
+	return Samurai{s}, err
+}
+
+// sudoku returns the receiver as a sudoku of the samurai layout.
+func (sam Samurai) sudoku() Sudoku {
+	if sam.s.layout == nil {
+		return Sudoku{layout: samurai}
+	}
+	return sam.s
+}
+
+// Solve solves the samurai sudoku, returning ErrConflict if there is no
+// solution.
+func (sam Samurai) Solve() (Samurai, error) {
+	solved, err := sam.sudoku().Solve()
+	return Samurai{solved}, err
+}
+
+// CountSolutions returns the number of solutions, stopping at limit, see
+// Sudoku.CountSolutions.
+func (sam Samurai) CountSolutions(limit int) int {
+	return sam.sudoku().CountSolutions(limit)
+}
+
+// IsUnique reports whether the receiver has exactly one solution.
+func (sam Samurai) IsUnique() bool {
+	return sam.sudoku().IsUnique()
+}
+
+// WithValueAt returns a new samurai sudoku with the square in the given row
+// and column of the board filled in, both counting from zero. If the square
+// isn't covered by any grid or can't take the value, ErrConflict is returned.
+func (sam Samurai) WithValueAt(row, col int, sv uint8) (Samurai, error) {
+	if row < 0 || row >= samuraiWidth || col < 0 || col >= samuraiWidth || samuraiSquares[row][col] < 0 || sv < 1 || sv > 9 {
+		return sam, ErrConflict
+	}
+	s, err := sam.sudoku().withClue(coordinate(samuraiSquares[row][col]), sv)
+	return Samurai{s}, err
+}
+
+// Grid returns one of the five sudokus of the receiver, TopLeft to
+// BottomRight. The values are all given as clues. For any other grid,
+// ErrUnknownGrid is returned.
+func (sam Samurai) Grid(g int) (Sudoku, error) {
+	if g < 0 || g >= len(samuraiGrids) {
+		return Sudoku{}, ErrUnknownGrid
+	}
+	s := sam.sudoku()
+	offset := samuraiGrids[g]
+	clues := make([]uint8, 81)
+	for c := range clues {
+		clues[c] = s.value(coordinate(samuraiSquares[offset[0]+c/9][offset[1]+c%9]))
+	}
+	return withClues(Sudoku{}.clone(), clues)
+}
+
+// String returns the board with lines separating the boxes, like
+// Sudoku.String does for a single sudoku. Squares not covered by any grid are
+// left blank.
+func (sam Samurai) String() string {
+	s := sam.sudoku()
+	boxes := samuraiWidth / 3
+
+	var res string
+	for row := 0; row < samuraiWidth; row++ {
+		var line string
+		for b := 0; b < boxes; b++ {
+			switch {
+			case b == 0:
+			case samuraiBox(row/3, b-1) || samuraiBox(row/3, b):
+				line += "|"
+			default:
+				line += " "
+			}
+			for col := 3 * b; col < 3*b+3; col++ {
+				if c := samuraiSquares[row][col]; c >= 0 {
+					line += string(samurai.digits[s.value(coordinate(c))]) + " "
+				} else {
+					line += "  "
+				}
+			}
+		}
+		res += strings.TrimRight(line, " ") + "\n"
+
+		if row%3 != 2 || row == samuraiWidth-1 {
+			continue
+		}
+		line = ""
+		for b := 0; b < boxes; b++ {
+			covered := func(b int) bool {
+				return b >= 0 && b < boxes && (samuraiBox(row/3, b) || samuraiBox(row/3+1, b))
+			}
+			switch {
+			case b == 0:
+			case covered(b-1) || covered(b):
+				line += "+"
+			default:
+				line += " "
+			}
+			if covered(b) {
+				line += "------"
+			} else {
+				line += "      "
+			}
+		}
+		res += strings.TrimRight(line, " ") + "\n"
+	}
+	return res
+}
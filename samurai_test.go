@@ -0,0 +1,135 @@
+package sudoku
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+const samuraiSudoku = `
+1 8 . |. . 5 |. . . |      |. . . |. . 9 |1 4 .
+2 . . |. 9 4 |. . . |      |1 . 2 |. . 5 |. . .
+. . 3 |. 8 . |. . . |      |. 8 . |. . 3 |. . .
+------+------+------+      +------+------+------
+6 . 9 |. . . |. . . |      |. . . |. 4 . |9 . .
+. . . |6 . 9 |. 4 . |      |4 . 1 |9 . 8 |2 5 .
+. 3 . |7 5 . |. . . |      |. . . |. . . |. . .
+------+------+------+------+------+------+------
+. 6 . |. . 2 |. . . |. . 3 |. . . |. . . |6 . .
+. 9 . |. 3 . |. . . |. . . |. . . |. 5 . |. . .
+5 . 2 |. . . |. . 3 |7 . . |. . . |7 . . |. 8 2
+------+------+------+------+------+------+------
+             |. 4 1 |. 5 . |. . . |
+             |. . . |. . . |. . 1 |
+             |5 . . |. . 6 |. . . |
+------+------+------+------+------+------+------
+. . . |6 . . |. 9 . |. 1 . |. . . |. 2 1 |. . .
+. . . |. 2 4 |. . . |2 7 . |. . 6 |. . . |. . .
+. . . |. . . |. . . |3 . . |. . . |5 . . |7 . 6
+------+------+------+------+------+------+------
+3 . . |. . 8 |1 . . |      |. . . |. . 4 |1 . .
+. 8 . |. . . |. . . |      |6 . 4 |. . . |. . .
+6 7 9 |. . . |. . . |      |. 3 . |. . . |2 . .
+------+------+------+      +------+------+------
+8 . . |. 4 . |. 3 . |      |. . . |. . 5 |. . 7
+1 6 . |. . . |7 2 . |      |8 6 . |1 . . |5 . .
+. 9 . |. 1 . |. . . |      |. . 1 |. 9 3 |. . .
+`
+
+func TestSamurai(t *testing.T) {
+	s, err := ParseSamurai(samuraiSudoku)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual := s.String(); actual != samuraiSudoku[1:] {
+		t.Error("Expected\n", samuraiSudoku[1:], "but got\n", actual)
+	}
+	if !s.IsUnique() {
+		t.Error("Expected a unique solution")
+	}
+
+	solved, err := s.Solve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `1 8 6 |2 7 5 |4 3 9 |      |3 5 7 |8 2 9 |1 4 6
+2 7 5 |3 9 4 |8 6 1 |      |1 6 2 |4 7 5 |8 3 9
+9 4 3 |1 8 6 |2 7 5 |      |9 8 4 |6 1 3 |5 2 7
+------+------+------+      +------+------+------
+6 2 9 |8 4 3 |5 1 7 |      |5 2 3 |1 4 7 |9 6 8
+7 5 1 |6 2 9 |3 4 8 |      |4 7 1 |9 6 8 |2 5 3
+8 3 4 |7 5 1 |6 9 2 |      |8 9 6 |5 3 2 |7 1 4
+------+------+------+------+------+------+------
+3 6 8 |9 1 2 |7 5 4 |8 6 3 |2 1 9 |3 8 4 |6 7 5
+4 9 7 |5 3 8 |1 2 6 |5 9 4 |7 3 8 |2 5 6 |4 9 1
+5 1 2 |4 6 7 |9 8 3 |7 2 1 |6 4 5 |7 9 1 |3 8 2
+------+------+------+------+------+------+------
+             |2 4 1 |9 5 7 |8 6 3 |
+             |8 6 9 |4 3 2 |5 7 1 |
+             |5 3 7 |1 8 6 |9 2 4 |
+------+------+------+------+------+------+------
+7 1 8 |6 3 5 |4 9 2 |6 1 5 |3 8 7 |6 2 1 |4 9 5
+9 5 6 |7 2 4 |3 1 8 |2 7 9 |4 5 6 |9 3 7 |8 2 1
+2 3 4 |8 9 1 |6 7 5 |3 4 8 |1 9 2 |5 4 8 |7 3 6
+------+------+------+------+------+------+------
+3 4 2 |9 6 8 |1 5 7 |      |7 2 5 |3 8 4 |1 6 9
+5 8 1 |4 7 3 |2 6 9 |      |6 1 4 |2 5 9 |3 7 8
+6 7 9 |1 5 2 |8 4 3 |      |9 3 8 |7 1 6 |2 5 4
+------+------+------+      +------+------+------
+8 2 7 |5 4 6 |9 3 1 |      |2 4 3 |8 6 5 |9 1 7
+1 6 5 |3 8 9 |7 2 4 |      |8 6 9 |1 7 2 |5 4 3
+4 9 3 |2 1 7 |5 8 6 |      |5 7 1 |4 9 3 |6 8 2
+`
+	if actual := solved.String(); actual != expected {
+		t.Error("Expected\n", expected, "but got\n", actual)
+	}
+
+	for g := TopLeft; g <= BottomRight; g++ {
+		grid, err := solved.Grid(g)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertIsSolved(grid, t)
+	}
+	if centre, _ := solved.Grid(Centre); centre.AsInts()[0][0] != 7 || centre.AsInts()[8][8] != 2 {
+		t.Error("Expected the centre grid to share the corner boxes, but got\n", centre)
+	}
+}
+
+func TestSamuraiShared(t *testing.T) {
+	s, err := Samurai{}.WithValueAt(6, 6, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// row 6 of the board is the first row of the centre grid up to column 14
+	if _, err := s.WithValueAt(6, 14, 5); err != ErrConflict {
+		t.Error("Expected ErrConflict in the centre grid, but got", err)
+	}
+	if _, err := s.WithValueAt(6, 15, 5); err != nil {
+		t.Error("Expected no conflict outside of the centre grid, but got", err)
+	}
+	if _, err := s.WithValueAt(0, 9, 1); err != ErrConflict {
+		t.Error("Expected ErrConflict outside of the grids, but got", err)
+	}
+
+	solved, err := Samurai{}.Solve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for g := TopLeft; g <= BottomRight; g++ {
+		grid, _ := solved.Grid(g)
+		assertIsSolved(grid, t)
+	}
+	for _, g := range []int{-1, BottomRight + 1} {
+		if _, err := solved.Grid(g); err != ErrUnknownGrid {
+			t.Error("Expected ErrUnknownGrid for", g, "but got", err)
+		}
+	}
+}
+
+func TestSamuraiParseError(t *testing.T) {
+	_, err := ParseSamurai(strings.Repeat(".", 200))
+	if perr, ok := err.(*ParseError); !ok || perr.Cell != "M3" || perr.Err != io.ErrUnexpectedEOF {
+		t.Error("Expected the input to end in M3, but got", err)
+	}
+}
@@ -0,0 +1,182 @@
+package sudoku
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Sandwich sudokus
+
+var (
+	// ErrInvalidSandwiches is returned by Sandwiches if the clues can't be
+	// read.
+	ErrInvalidSandwiches = fmt.Errorf("Invalid sandwich clues")
+)
+
+// Sandwiches returns a constraint for the clues of a sandwich sudoku. A clue
+// is the sum of the values between the smallest and the largest value of its
+// row or column, 1 and 9 in a standard sudoku. The clues are given as fields
+// separated by whitespace, first one for every column from left to right,
+// then one for every row from top to bottom. A dot stands for a missing clue,
+// for example
+//
+//	10 . 0 35 . . 7 . 12
+//	. 5 . . 23 . . 9 .
+//
+// If the number of fields doesn't fit a board, or a field is neither a dot
+// nor a number, ErrInvalidSandwiches is returned.
+//
+// When the sudoku is printed, the clues of the columns are written above the
+// grid from top to bottom, and the clues of the rows right beside it. Parse
+// doesn't ignore them, only the grid itself can be read again.
+func Sandwiches(clues string) (Constraint, error) {
+	fields := strings.Fields(clues)
+	size := 0
+	for box := 2; box < len(layouts); box++ {
+		if 2*box*box == len(fields) {
+			size = box * box
+		}
+	}
+	if size == 0 {
+		return nil, ErrInvalidSandwiches
+	}
+
+	sw := sandwiches{sums: make([]int, len(fields))}
+	for i, field := range fields {
+		sw.sums[i] = -1
+		if field == "." {
+			continue
+		}
+		sum, err := strconv.Atoi(field)
+		if err != nil || sum < 0 {
+			return nil, ErrInvalidSandwiches
+		}
+		sw.sums[i] = sum
+	}
+	return sw, nil
+}
+
+type sandwiches struct {
+	relational
+	// sums holds the clues of the columns followed by those of the rows, -1
+	// if there is no clue.
+	sums []int
+}
+
+// line returns the squares of the column or row with the clue i.
+func (sw sandwiches) line(l *layout, i int) []coordinate {
+	res := make([]coordinate, l.size)
+	for j := range res {
+		if i < l.size {
+			res[j] = l.coord(j, i)
+		} else {
+			res[j] = l.coord(i-l.size, j)
+		}
+	}
+	return res
+}
+
+func (sw sandwiches) restrict(p propagator) error {
+	if len(sw.sums) != 2*p.s.layout.size {
+		return ErrInvalidConstraints
+	}
+	for i := range sw.sums {
+		if err := sw.restrictLine(p, i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (sw sandwiches) propagate(p propagator, c coordinate, sv uint8) error {
+	l := p.s.layout
+	if err := sw.restrictLine(p, int(c)%l.size); err != nil {
+		return err
+	}
+	return sw.restrictLine(p, l.size+int(c)/l.size)
+}
+
+// restrictLine eliminates the values that don't fit any placement of the
+// smallest and largest value, the bread, in the column or row with the clue
+// i.
+func (sw sandwiches) restrictLine(p propagator, i int) error {
+	sum := sw.sums[i]
+	if sum < 0 {
+		return nil
+	}
+	l := p.s.layout
+	cells := sw.line(l, i)
+	bread := uint32(1)<<1 | uint32(1)<<uint(l.size)
+
+	supported := make([]uint32, len(cells))
+	for first := range cells {
+		for second := range cells {
+			if first == second || p.possible(cells[first])&(1<<1) == 0 || p.possible(cells[second])&(1<<uint(l.size)) == 0 {
+				continue
+			}
+			from, to := first+1, second
+			if second < first {
+				from, to = second+1, first
+			}
+
+			rest, open, filling := sum, 0, uint32(0)
+			var allowed uint32
+			for _, c := range cells[from:to] {
+				if v := p.s.value(c); v != 0 {
+					rest -= int(v)
+					filling |= 1 << v
+				} else {
+					open++
+					allowed |= p.s.candidates(c)
+				}
+			}
+			if filling&bread != 0 {
+				continue
+			}
+			if open == 0 && rest != 0 {
+				continue
+			}
+			if open > 0 {
+				combinations := combinationValues(allowed&^bread, open, rest)
+				if combinations == 0 {
+					continue
+				}
+				filling |= combinations
+			}
+
+			for k := range cells {
+				switch {
+				case k == first:
+					supported[k] |= 1 << 1
+				case k == second:
+					supported[k] |= 1 << uint(l.size)
+				case k >= from && k < to:
+					supported[k] |= filling
+				default:
+					supported[k] |= l.allValues &^ bread
+				}
+			}
+		}
+	}
+
+	for k, c := range cells {
+		for _, sv := range valuesOf(p.possible(c) &^ supported[k]) {
+			if err := p.eliminate(c, sv); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// frame returns the clues for String.
+func (sw sandwiches) frame(l *layout) (columns, rows []string) {
+	clues := make([]string, len(sw.sums))
+	for i, sum := range sw.sums {
+		if sum >= 0 {
+			clues[i] = strconv.Itoa(sum)
+		}
+	}
+	return clues[:l.size], clues[l.size:]
+}
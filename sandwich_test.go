@@ -0,0 +1,86 @@
+package sudoku
+
+import (
+	"strings"
+	"testing"
+)
+
+const (
+	sandwichClues  = "13 2 0 25 13 19 5 23 6\n4 20 0 6 11 18 0 0 4"
+	sandwichSudoku = "3..............8...............8.................................9..............2"
+)
+
+func TestSandwiches(t *testing.T) {
+	opts := ParseOptions{Sandwiches: sandwichClues}
+	s, err := opts.Parse(sandwichSudoku)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.IsUnique() {
+		t.Error("Expected a unique solution")
+	}
+
+	solved, err := s.Solve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertIsSolved(solved, t)
+
+	expected := `1      2 1 1    2
+3 2 0  5 3 9  5 3 6
+3 8 7 |6 2 1 |4 9 5   4
+4 5 6 |9 3 7 |8 2 1   20
+1 9 2 |5 4 8 |7 3 6   0
+------+------+------
+7 2 5 |3 8 4 |1 6 9   6
+6 1 4 |2 5 9 |3 7 8   11
+9 3 8 |7 1 6 |2 5 4   18
+------+------+------
+2 4 3 |8 6 5 |9 1 7   0
+8 6 9 |1 7 2 |5 4 3   0
+5 7 1 |4 9 3 |6 8 2   4
+`
+	if actual := solved.String(); actual != expected {
+		t.Error("Expected\n", expected, "but got\n", actual)
+	}
+}
+
+func TestSandwichesPropagate(t *testing.T) {
+	s, err := ParseOptions{Sandwiches: "35 . . . . . . . . . . . . 0 . . . ."}.Parse(strings.Repeat(".", 81))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 35 is the sum of 2 to 8, so 1 and 9 have to be at both ends
+	for _, c := range []coordinate{coord('A', '1'), coord('I', '1')} {
+		if actual := valuesOf(s.candidates(c)); !equalValues(actual, []uint8{1, 9}) {
+			t.Error("Expected 1 and 9 in", c, "but got", actual)
+		}
+	}
+	if actual := valuesOf(s.candidates(coord('E', '1'))); !equalValues(actual, []uint8{2, 3, 4, 5, 6, 7, 8}) {
+		t.Error("Expected 2 to 8 in E1, but got", actual)
+	}
+
+	// with 0, 1 and 9 are next to each other
+	if s, err = s.WithCellValued('E', '5', 1); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []coordinate{coord('E', '2'), coord('E', '3'), coord('E', '7'), coord('E', '8'), coord('E', '9')} {
+		if s.candidates(c)&(1<<9) != 0 {
+			t.Error("Expected 9 to be eliminated from", c)
+		}
+	}
+	if _, err := s.WithCellValued('E', '8', 9); err != ErrConflict {
+		t.Error("Expected ErrConflict for a sandwich that isn't empty, but got", err)
+	}
+}
+
+func TestSandwichesInvalid(t *testing.T) {
+	for _, clues := range []string{"1 2 3", "a . . . . . . ." + strings.Repeat(" .", 10), "-1" + strings.Repeat(" .", 17)} {
+		if _, err := Sandwiches(clues); err != ErrInvalidSandwiches {
+			t.Error("Expected ErrInvalidSandwiches for", clues, "but got", err)
+		}
+	}
+	if _, err := (ParseOptions{BoxSize: 2, Sandwiches: sandwichClues}).Parse(strings.Repeat(".", 16)); err != ErrInvalidConstraints {
+		t.Error("Expected ErrInvalidConstraints for clues of another size, but got", err)
+	}
+}
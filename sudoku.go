@@ -12,27 +12,90 @@
 // simply try every possibility and reject conflicting moves. For optimization,
 // the square with the least possibilities is filled first.
 //
+// Besides the standard 9x9 sudoku, boards with boxes of 2x2 (4x4 sudokus),
+// 4x4 (16x16) and 5x5 (25x25) are supported, see ParseOptions.
+//
 // Anytime a function returns a sudoku and/or an error, the sudoku is only
 // valid if the error is nil.
 package sudoku
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"math/rand"
 	"strings"
+	"unicode"
 )
 
 var (
 	// ErrConflict is returned when there is a conflict that prevents finding a
 	// solution or assigning a value.
 	ErrConflict = fmt.Errorf("Conflict")
+	// ErrUnexpectedRune is returned in a ParseError when parsing strictly,
+	// if a rune is neither a value nor part of the lines drawn by String.
+	ErrUnexpectedRune = fmt.Errorf("Unexpected character")
+	// ErrTrailingSquares is returned in a ParseError when parsing strictly,
+	// if there are squares left after the last one.
+	ErrTrailingSquares = fmt.Errorf("Trailing squares")
 )
 
-// A Sudoku is an immutable value, it contains the 81 fields of a standard
-// playing field. An array is used instead of a slice because arrays are not
-// passed by reference.
+// A Sudoku is an immutable value, it contains the fields of the playing
+// field, 81 for a standard one. The fields are kept in slices, which are
+// passed by reference. Therefore they are never modified once a Sudoku has
+// been handed out, anything that changes fields works on a clone.
+//
+// The zero value is an empty standard sudoku.
 type Sudoku struct {
-	cells [81]square
+	cells []square
+	// clues holds the values that were given explicitly, by parsing or by
+	// WithCellValued, as opposed to the ones found by propagation or search.
+	// Zero means that there is no clue for that square.
+	clues []uint8
+	// masks holds the values every square is restricted to from the start,
+	// by parsing or WithCandidatesAt, as a bitset like candidates. Zero or a
+	// nil slice mean no restriction. The slice is shared between clones, and
+	// replaced instead of modified.
+	masks []uint32
+	// layout describes the board, nil meaning the standard 9x9 one.
+	layout *layout
+}
+
+// shape returns the layout of the receiver.
+func (s Sudoku) shape() *layout {
+	if s.layout == nil {
+		return layouts[3]
+	}
+	return s.layout
+}
+
+// clone returns a copy of the receiver that can be modified without
+// affecting the receiver. Zero values are turned into empty squares.
+func (s Sudoku) clone() Sudoku {
+	l := s.shape()
+	res := Sudoku{
+		cells:  make([]square, l.numCells),
+		clues:  make([]uint8, l.numCells),
+		masks:  s.masks,
+		layout: l,
+	}
+	copy(res.cells, s.cells)
+	copy(res.clues, s.clues)
+
+	for c := range res.cells {
+		if res.cells[c] == nil {
+			res.cells[c] = emptySquare{}
+		}
+	}
+	return res
+}
+
+// square returns the square at c, treating the zero value as empty.
+func (s Sudoku) square(c coordinate) square {
+	if int(c) >= len(s.cells) || s.cells[c] == nil {
+		return emptySquare{}
+	}
+	return s.cells[c]
 }
 
 // The two different types of squares do not share methods, so we are using the
@@ -41,42 +104,43 @@ type Sudoku struct {
 type square interface{}
 
 // A filled out square is just the value it represents. Uint8 is sufficient, we
-// are only storing values from 1 to 9.
+// are only storing values from 1 to 25.
 type filledOutSquare uint8
 
 // An empty square is more interesting. In addition to its emptiness (encoded
 // by the type) it also contains the information regarding already eliminated
 // values. If a value is eliminated i.e. it can't occur in this cell, its
-// corresponding bit is set in the eliminatedValues field. As it is uint16,
-// there is plenty of space for 9 different values.  Additionally, we cache the
-// number of eliminated values, since this is used later on.
+// corresponding bit is set in the eliminatedValues field. As it is uint32,
+// there is plenty of space for up to 25 different values.  Additionally, we
+// cache the number of eliminated values, since this is used later on.
 //
 // We are using a bitset instead of a possible map[uint]bool for efficiency (hopefully), but
-// mostly because uint16 values are passed by value.
+// mostly because uint32 values are passed by value.
 type emptySquare struct {
-	eliminatedValues         uint16
+	eliminatedValues         uint32
 	numberOfEliminatedValues uint8
 }
 
-// This method eliminates one possible value from this square. If there is only
-// one value left, it returns a filled square with this value, else it returns
-// the old square minus the given value.
-func (es emptySquare) eliminated(sv uint8) square {
+// This method eliminates one possible value from this square, which can take
+// values from 1 to size. If there is only one value left, it returns a filled
+// square with this value, else it returns the old square minus the given
+// value.
+func (es emptySquare) eliminated(sv uint8, size int) square {
 	if es.eliminatedValues&(1<<sv) == 0 {
 		es.numberOfEliminatedValues++
 		es.eliminatedValues |= 1 << sv
 	}
 
-	if es.numberOfEliminatedValues == 8 {
-		return filledOutSquare(es.possibleValues()[0])
+	if int(es.numberOfEliminatedValues) == size-1 {
+		return filledOutSquare(es.possibleValues(size)[0])
 	}
 
 	return es
 }
 
-func (es emptySquare) possibleValues() []uint8 {
+func (es emptySquare) possibleValues(size int) []uint8 {
 	var res []uint8
-	for i := uint8(1); i <= uint8(9); i++ {
+	for i := uint8(1); int(i) <= size; i++ {
 		if es.isValuePossible(i) {
 			res = append(res, i)
 		}
@@ -90,51 +154,360 @@ func (es emptySquare) isValuePossible(sv uint8) bool {
 
 // Parsing
 
-func parseCell(r, c rune, sudoku Sudoku, rr io.RuneReader) (Sudoku, error) {
-	var x rune
-	var err error
+// ParseOptions controls how sudokus are parsed. The zero value parses
+// standard 9x9 sudokus.
+type ParseOptions struct {
+	// BoxSize is the number of rows and columns in a box, from 2 to 5. The
+	// sudoku has BoxSize*BoxSize rows, columns and values. Zero means 3.
+	BoxSize int
+	// Constraints are the rules of the sudoku, nil meaning Classic(). See
+	// Constraint for the requirements.
+	Constraints []Constraint
+	// Variant adds the constraints of well-known variants to Constraints.
+	Variant Variant
+	// Regions replaces the boxes by irregular regions, given as a grid of
+	// labels as described for Jigsaw.
+	Regions string
+	// Cages adds the cages of a killer sudoku, given as described for Killer.
+	Cages string
+	// ExtraRegions adds regions that have to contain every value once, given
+	// as described for ExtraRegions.
+	ExtraRegions string
+	// Markers adds markers between adjacent squares, as in Kropki and XV
+	// sudokus, given as described for Markers.
+	Markers string
+	// Lines adds thermometers, arrows and greater-than signs, given in the
+	// JSON format described for Lines.
+	Lines string
+	// Sandwiches adds the clues of a sandwich sudoku, given as described for
+	// Sandwiches.
+	Sandwiches string
+	// Strict rejects input that ParseReader only accepts by ignoring runes,
+	// see there.
+	Strict bool
+}
+
+// layout returns the layout described by the options.
+func (opts ParseOptions) layout() (*layout, error) {
+	box := opts.BoxSize
+	if box == 0 {
+		box = 3
+	}
 
-	for x = ' '; x != '.' && (x < '0' || x > '9'); x, _, err = rr.ReadRune() {
+	constraints := opts.Constraints
+	if opts.Regions != "" {
+		regions, err := Jigsaw(opts.Regions)
+		if err != nil {
+			return nil, err
+		}
+		if constraints == nil {
+			constraints = Classic()
+		}
+		if len(constraints) < 3 {
+			return nil, ErrInvalidConstraints
+		}
+		constraints = append([]Constraint{constraints[0], constraints[1], regions}, constraints[3:]...)
+	}
+	var extra []Constraint
+	if opts.Cages != "" {
+		cages, err := Killer(opts.Cages)
+		if err != nil {
+			return nil, err
+		}
+		extra = append(extra, cages)
+	}
+	if opts.ExtraRegions != "" {
+		regions, err := ExtraRegions(opts.ExtraRegions)
+		if err != nil {
+			return nil, err
+		}
+		extra = append(extra, regions)
+	}
+	if opts.Markers != "" {
+		markers, err := Markers(opts.Markers)
+		if err != nil {
+			return nil, err
+		}
+		extra = append(extra, markers)
+	}
+	if opts.Lines != "" {
+		lines, err := Lines(opts.Lines)
+		if err != nil {
+			return nil, err
+		}
+		extra = append(extra, lines)
+	}
+	if opts.Sandwiches != "" {
+		sandwiches, err := Sandwiches(opts.Sandwiches)
 		if err != nil {
+			return nil, err
+		}
+		extra = append(extra, sandwiches)
+	}
+	extra = append(extra, opts.Variant.constraints()...)
+	if len(extra) > 0 {
+		if constraints == nil {
+			constraints = Classic()
+		}
+		constraints = append(constraints[:len(constraints):len(constraints)], extra...)
+	}
+	return layoutFor(box, constraints)
+}
+
+// A ParseError tells where reading a sudoku failed, so that the typo can be
+// found. Err is ErrConflict if a value or a set of candidates doesn't fit
+// into its square, or io.ErrUnexpectedEOF if the input ends before the last
+// square. When parsing strictly, it can also be ErrUnexpectedRune or
+// ErrTrailingSquares.
+type ParseError struct {
+	// Offset is the number of runes before the offending one, Line and
+	// Column give its position, counting from one. If the input ended, they
+	// point just behind the last rune.
+	Offset, Line, Column int
+	// Cell is the name of the square being read, as in D5, or the last
+	// square for ErrTrailingSquares. It is empty for unexpected runes after
+	// the last square.
+	Cell string
+	// Cells is the number of squares read before.
+	Cells int
+	// Digit is the value that doesn't fit, or the unexpected rune. It is zero
+	// if there is none.
+	Digit rune
+	// Peer is the name of a peer that already has the value, empty if the
+	// conflict has another cause.
+	Peer string
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	res := fmt.Sprintf("line %d, column %d: ", e.Line, e.Column)
+	switch e.Err {
+	case ErrUnexpectedRune:
+		if e.Cell == "" {
+			return res + fmt.Sprintf("%v %q after the last square", e.Err, e.Digit)
+		}
+		return res + fmt.Sprintf("%v %q in %s", e.Err, e.Digit, e.Cell)
+	case ErrTrailingSquares:
+		return res + fmt.Sprintf("%v after %s", e.Err, e.Cell)
+	case io.ErrUnexpectedEOF:
+		return res + fmt.Sprintf("%v in %s, after %d squares", e.Err, e.Cell, e.Cells)
+	}
+
+	res += fmt.Sprintf("%v in %s", e.Err, e.Cell)
+	switch {
+	case e.Peer != "":
+		res += fmt.Sprintf(", %c is already in %s", e.Digit, e.Peer)
+	case e.Digit != 0:
+		res += fmt.Sprintf(" with %c", e.Digit)
+	}
+	return res
+}
+
+// Unwrap returns Err, so that errors.Is(err, ErrConflict) holds for conflicts.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// A textPosition is the position of a rune in the input, see ParseError.
+type textPosition struct {
+	offset, line, column int
+}
+
+// A runeCounter keeps track of the position of the runes read.
+type runeCounter struct {
+	rr io.RuneReader
+	// last and next are the positions of the last rune read and the one
+	// following it.
+	last, next textPosition
+}
+
+func newRuneCounter(rr io.RuneReader) *runeCounter {
+	return &runeCounter{rr: rr, next: textPosition{0, 1, 1}}
+}
+
+func (rc *runeCounter) ReadRune() (rune, int, error) {
+	r, size, err := rc.rr.ReadRune()
+	if err != nil {
+		return r, size, err
+	}
+	rc.last = rc.next
+	rc.next.offset++
+	rc.next.column++
+	if r == '\n' {
+		rc.next.line++
+		rc.next.column = 1
+	}
+	return r, size, nil
+}
+
+// parseCells reads the values of all squares of sudoku from rc. If the input
+// ends before the first square, io.EOF is returned. Conflicts and input
+// ending later on are reported as ParseError. If strict is set, only values
+// and lines as drawn by String are accepted, up to the end of the input.
+func parseCells(sudoku Sudoku, rc *runeCounter, strict bool) (Sudoku, error) {
+	l := sudoku.shape()
+	var allowed func(r rune) bool
+	if strict {
+		allowed = l.drawnBy()
+	}
+
+	for c := range sudoku.cells {
+		before := sudoku
+		var digit rune
+		var err error
+		if sudoku, digit, err = parseCell(coordinate(c), sudoku, rc, allowed); err == nil {
+			continue
+		}
+
+		pos := rc.last
+		switch {
+		case err == io.EOF && c == 0:
+			return sudoku, err
+		case err == io.EOF || err == io.ErrUnexpectedEOF:
+			pos, err = rc.next, io.ErrUnexpectedEOF
+		case err != ErrConflict && err != ErrUnexpectedRune:
 			return sudoku, err
 		}
+		res := &ParseError{Offset: pos.offset, Line: pos.line, Column: pos.column, Cell: l.name(coordinate(c)), Cells: c, Err: err}
+		if err == ErrUnexpectedRune {
+			res.Digit = digit
+		} else if sv := l.valueOf(digit); sv != 0 {
+			res.Digit = digit
+			for _, p := range l.peers[c] {
+				if before.value(p) == sv {
+					res.Peer = l.name(p)
+					break
+				}
+			}
+		}
+		return sudoku, res
 	}
 
-	if x >= '1' && x <= '9' {
-		return sudoku.withAssignment(coord(r, c), uint8(x-'0'))
+	for strict {
+		x, _, err := rc.ReadRune()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return sudoku, err
+		}
+		if allowed(x) {
+			continue
+		}
+		res := &ParseError{Offset: rc.last.offset, Line: rc.last.line, Column: rc.last.column, Cell: l.name(coordinate(l.numCells - 1)), Cells: l.numCells, Err: ErrTrailingSquares}
+		if x != '.' && x != '0' && x != '[' && l.valueOf(x) == 0 {
+			res.Cell, res.Digit, res.Err = "", x, ErrUnexpectedRune
+		}
+		return sudoku, res
 	}
 	return sudoku, nil
 }
 
+// drawnBy returns whether a rune can be part of the lines and marks drawn by
+// String, or is whitespace.
+func (l *layout) drawnBy() func(r rune) bool {
+	runes := "|-+"
+	for c := 0; c < l.numCells; c++ {
+		if mark, marked := l.mark(coordinate(c), true); marked {
+			runes += string(mark)
+		}
+	}
+	return func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune(runes, r)
+	}
+}
+
+// parseCell reads the square at c, returning the rune of its value, if any.
+// Runes that aren't values are skipped if allowed is nil or accepts them,
+// otherwise ErrUnexpectedRune is returned along with the rune.
+func parseCell(c coordinate, sudoku Sudoku, rr io.RuneReader, allowed func(r rune) bool) (Sudoku, rune, error) {
+	l := sudoku.shape()
+	var x rune
+	var err error
+
+	for x = ' '; x != '.' && x != '0' && x != '[' && l.valueOf(x) == 0; x, _, err = rr.ReadRune() {
+		if err != nil {
+			return sudoku, 0, err
+		}
+		if allowed != nil && !allowed(x) {
+			return sudoku, x, ErrUnexpectedRune
+		}
+	}
+
+	if x == '[' {
+		var mask uint32
+		for x != ']' {
+			if x, _, err = rr.ReadRune(); err == io.EOF {
+				// the square has been started, so the input isn't just empty
+				return sudoku, 0, io.ErrUnexpectedEOF
+			} else if err != nil {
+				return sudoku, 0, err
+			}
+			if sv := l.valueOf(x); sv != 0 {
+				mask |= 1 << sv
+			} else if allowed != nil && x != ']' {
+				return sudoku, x, ErrUnexpectedRune
+			}
+		}
+		sudoku, err = sudoku.withMask(c, mask)
+		return sudoku, 0, err
+	}
+	if sv := l.valueOf(x); sv != 0 {
+		sudoku, err = sudoku.withClue(c, sv)
+		return sudoku, x, err
+	}
+	return sudoku, 0, nil
+}
+
 // ParseReader reads a complete sudoku from the given rune reader. The
 // following semantics apply:
 //
-// * Any digit except zero fills the cell directly. If a conflict arises (same
-// number in same column, for example), an error is returned.
+// * Any value fills the cell directly. If a conflict arises (same number in
+// same column, for example), an error is returned. The values are the digits
+// 1 to 9 for sizes up to 9x9. 16x16 sudokus use 1-9 and A-G, 25x25 sudokus
+// use A-Y. Letters are accepted in upper and lower case.
 //
 // * A zero or dot (0 or .) are interpreted as empty field.
 //
+// * Values in square brackets, such as [13579], leave the field empty, but
+// restrict it to those values, see WithCandidatesAt. This is how the shaded
+// fields of odd/even sudokus are given.
+//
 // * Any other rune is ignored.
 //
 // Thanks to this it is possible to parse a sudoku in complex format as well as
-// in a single row.
-func ParseReader(rr io.RuneReader) (Sudoku, error) {
-	var sudoku Sudoku
-
-	for ind := range sudoku.cells {
-		sudoku.cells[ind] = emptySquare{}
+// in a single row. If Strict is set, only whitespace and the lines and marks
+// drawn by String are ignored, any other rune is reported as
+// ErrUnexpectedRune. The input has to end after the last square then, so
+// that it contains a single sudoku, a value or empty square after it is
+// reported as ErrTrailingSquares. This way input with stray runes doesn't go
+// unnoticed.
+//
+// If the input ends before the first square, io.EOF is returned. Any other
+// problem with the input, a conflict or the input ending too early, is
+// returned as *ParseError.
+func (opts ParseOptions) ParseReader(rr io.RuneReader) (Sudoku, error) {
+	l, err := opts.layout()
+	if err != nil {
+		return Sudoku{}, err
 	}
 
-	for r := 'A'; r <= 'I'; r++ {
-		for c := '1'; c <= '9'; c++ {
-			var err error
-			if sudoku, err = parseCell(r, c, sudoku, rr); err != nil {
-				return sudoku, err
-			}
-		}
+	sudoku, err := l.empty()
+	if err != nil {
+		return sudoku, err
 	}
+	return parseCells(sudoku, newRuneCounter(rr), opts.Strict)
+}
 
-	return sudoku, nil
+// Parse is a convenience wrapper for ParseReader that accepts a string.
+func (opts ParseOptions) Parse(s string) (Sudoku, error) {
+	return opts.ParseReader(strings.NewReader(s))
+}
+
+// ParseReader reads a standard 9x9 sudoku, see ParseOptions.ParseReader for
+// details.
+func ParseReader(rr io.RuneReader) (Sudoku, error) {
+	return ParseOptions{}.ParseReader(rr)
 }
 
 // Parse is a convenience wrapper for ParseReader that accepts a string. See
@@ -150,15 +523,146 @@ func Parse(s string) (Sudoku, error) {
 // If there are multiple solutions to a sudoku, i.e. it's underspecified, one
 // of them is returned.
 func (s Sudoku) Solve() (Sudoku, error) {
+	return s.SolveContext(context.Background())
+}
+
+// SolveContext is like Solve, but gives up as soon as ctx is done. In that
+// case the error returned by ctx.Err() is returned, so a timeout can be told
+// apart from ErrConflict.
+func (s Sudoku) SolveContext(ctx context.Context) (Sudoku, error) {
+	solution, _, err := s.solve(ctx)
+	return solution, err
+}
+
+// Stats describes the work done by the solver. In contrast to the time taken,
+// these numbers only depend on the sudoku, so they can be used to compare
+// puzzles regardless of the machine they are solved on.
+type Stats struct {
+	// Nodes is the number of search nodes visited.
+	Nodes int
+	// Guesses is the number of values tried for squares that had more than one
+	// possibility left.
+	Guesses int
+	// Backtracks is the number of tried values that turned out to be wrong.
+	Backtracks int
+	// MaxDepth is the deepest level of recursion reached, zero if the sudoku
+	// was solved without any search.
+	MaxDepth int
+	// Assignments is the number of squares filled in, including the ones
+	// filled in by constraint propagation.
+	Assignments int
+}
+
+// SolveWithStats is like Solve, but additionally reports statistics about the
+// search. The statistics are valid even if an error is returned.
+func (s Sudoku) SolveWithStats() (Sudoku, Stats, error) {
+	return s.solve(context.Background())
+}
+
+func (s Sudoku) solve(ctx context.Context) (Sudoku, Stats, error) {
+	var solution Sudoku
+	found := false
+	se := searcher{
+		done: ctx.Done(),
+		found: func(solved Sudoku) bool {
+			solution, found = solved, true
+			return false
+		},
+	}
+	se.search(s, 0)
+
+	if !found {
+		if se.aborted {
+			return s, se.stats, ctx.Err()
+		}
+		return s, se.stats, ErrConflict
+	}
+	return solution, se.stats, nil
+}
+
+// CountSolutions returns the number of solutions of the receiver. The search
+// stops as soon as limit solutions have been found, so the result is never
+// larger than limit. A limit of zero or less counts every solution, which can
+// take a very long time for sparse sudokus.
+func (s Sudoku) CountSolutions(limit int) int {
+	count := 0
+	se := searcher{found: func(Sudoku) bool {
+		count++
+		return limit <= 0 || count < limit
+	}}
+	se.search(s, 0)
+	return count
+}
+
+// IsUnique reports whether the receiver has exactly one solution. Sudokus
+// without any solution are not unique.
+func (s Sudoku) IsUnique() bool {
+	return s.CountSolutions(2) == 1
+}
+
+// ForEachSolution calls fn for every solution of the receiver, in the order
+// Solve would find them. The enumeration stops early as soon as fn returns
+// false. Beware that sparse sudokus have an enormous number of solutions.
+func (s Sudoku) ForEachSolution(fn func(Sudoku) bool) {
+	se := searcher{found: fn}
+	se.search(s, 0)
+}
+
+// A searcher holds the state shared by all nodes of a single search.
+type searcher struct {
+	// found is called for every solution, the search stops as soon as it
+	// returns false.
+	found func(Sudoku) bool
+	// done aborts the search once it is closed. A nil channel never aborts.
+	done <-chan struct{}
+	// aborted is set if the search was stopped because done was closed.
+	aborted bool
+	stats   Stats
+	// rnd, if set, shuffles the order in which the possible values of a
+	// square are tried.
+	rnd *rand.Rand
+}
+
+// search is the core of the solver. It fills in the square with the least
+// possibilities with every remaining value in turn, and continues recursively
+// until either a conflict occurs or the sudoku is solved. Every solution is
+// passed to se.found. The return value reports whether the search ran to
+// completion.
+func (se *searcher) search(s Sudoku, depth int) bool {
+	se.stats.Nodes++
+	if depth > se.stats.MaxDepth {
+		se.stats.MaxDepth = depth
+	}
+
+	select {
+	case <-se.done:
+		se.aborted = true
+		return false
+	default:
+	}
+
+	// accept zero state as empty sudoku
+	if s.cells == nil {
+		var err error
+		if s, err = s.shape().empty(); err != nil {
+			return true
+		}
+	}
+
+	// on the larger boards, the values tried below are followed by hidden
+	// singles right away, the starting point needs it here
+	hiddenSingles := s.layout.numCells > 81
+	if depth == 0 && hiddenSingles {
+		var err error
+		if s, err = s.withHiddenSingles(&se.stats.Assignments); err != nil {
+			return true
+		}
+	}
+
 	var coordWithMaximumEliminatedValues coordinate
 	maximumEliminatedValues := uint8(0)
 	solved := true
 	for coord, x := range s.cells {
-		// accept zero state as empty square
-		if x == nil {
-			s.cells[coord] = emptySquare{}
-			x = s.cells[coord]
-		}
 		if val, ok := x.(emptySquare); ok {
 			solved = false
 
@@ -169,60 +673,241 @@ func (s Sudoku) Solve() (Sudoku, error) {
 		}
 	}
 	if solved {
-		return s, nil
+		return se.found(s)
 	}
 
-	for _, sv := range s.cells[coordWithMaximumEliminatedValues].(emptySquare).possibleValues() {
-		news, err := s.withAssignment(coordWithMaximumEliminatedValues, sv)
+	possibilities := s.cells[coordWithMaximumEliminatedValues].(emptySquare).possibleValues(s.layout.size)
+	if se.rnd != nil {
+		se.rnd.Shuffle(len(possibilities), func(i, j int) {
+			possibilities[i], possibilities[j] = possibilities[j], possibilities[i]
+		})
+	}
+	for _, sv := range possibilities {
+		if len(possibilities) > 1 {
+			se.stats.Guesses++
+		}
+
+		news, err := s.withCountedAssignment(coordWithMaximumEliminatedValues, sv, &se.stats.Assignments)
+		if err == nil && hiddenSingles {
+			news, err = news.withHiddenSingles(&se.stats.Assignments)
+		}
 		if err != nil {
+			se.stats.Backtracks++
 			continue
 		}
 
-		if solved, err := news.Solve(); err == nil {
-			return solved, nil
+		if !se.search(news, depth+1) {
+			return false
+		}
+		se.stats.Backtracks++
+	}
+	return true
+}
+
+// withHiddenSingles fills in every value that has only a single square left
+// in one of its units, until there are none left. Propagation alone only
+// fills in squares with a single value left, which is not enough to keep the
+// search from getting lost on boards of more than 81 squares, such as 16x16
+// or samurai sudokus, which is why search uses it there. Smaller boards are
+// searched without it, so that standard sudokus keep their order of
+// solutions. If a value has no square left in a unit, an error is returned.
+func (s Sudoku) withHiddenSingles(count *int) (Sudoku, error) {
+	l := s.layout
+	for changed := true; changed; {
+		changed = false
+		for _, unit := range l.units {
+			var once, twice, filled uint32
+			for _, c := range unit {
+				switch sq := s.cells[c].(type) {
+				case filledOutSquare:
+					filled |= 1 << uint8(sq)
+				case emptySquare:
+					possible := l.allValues &^ sq.eliminatedValues
+					twice |= once & possible
+					once |= possible
+				}
+			}
+			if (once|filled)&l.allValues != l.allValues {
+				return s, ErrConflict
+			}
+
+			for _, sv := range valuesOf(once &^ twice &^ filled) {
+				for _, c := range unit {
+					if es, ok := s.cells[c].(emptySquare); ok && es.isValuePossible(sv) {
+						var err error
+						if s, err = s.withCountedAssignment(c, sv, count); err != nil {
+							return s, err
+						}
+						changed = true
+						break
+					}
+				}
+			}
 		}
 	}
-	return s, ErrConflict
+	return s, nil
 }
 
 // WithCellValued returns a new sudoku with the field at position rc filled in
 // with the given value.  If a conflict arises due to this assignment, an error
-// is returned.
+// is returned. Rows are given as letters starting with 'A', columns as digits
+// starting with '1', which limits this method to sudokus of up to 9x9, see
+// WithValueAt for larger ones.
 func (s Sudoku) WithCellValued(r, c rune, sv uint8) (Sudoku, error) {
-	return s.withAssignment(coord(r, c), sv)
+	return s.WithValueAt(int(r-'A'), int(c-'1'), sv)
+}
+
+// WithValueAt works like WithCellValued, but takes the row and column as
+// numbers counting from zero.
+func (s Sudoku) WithValueAt(row, col int, sv uint8) (Sudoku, error) {
+	l := s.shape()
+	if row < 0 || row >= l.size || col < 0 || col >= l.size || sv < 1 || int(sv) > l.size {
+		return s, ErrConflict
+	}
+	return s.withClue(l.coord(row, col), sv)
+}
+
+// WithCandidatesAt returns a new sudoku in which the square in the given row
+// and column, both counting from zero, is restricted to the given values, as
+// for the shaded squares of odd/even sudokus. Restrictions of the same square
+// add up. If that leaves no value for the square, ErrConflict is returned.
+// The restrictions are kept by Minimize, and shown by String.
+func (s Sudoku) WithCandidatesAt(row, col int, values ...uint8) (Sudoku, error) {
+	l := s.shape()
+	if row < 0 || row >= l.size || col < 0 || col >= l.size {
+		return s, ErrConflict
+	}
+	var mask uint32
+	for _, sv := range values {
+		if sv < 1 || int(sv) > l.size {
+			return s, ErrConflict
+		}
+		mask |= 1 << sv
+	}
+	return s.withMask(l.coord(row, col), mask)
+}
+
+// withMask restricts the square at c to the values in mask, and remembers
+// the restriction.
+func (s Sudoku) withMask(c coordinate, mask uint32) (Sudoku, error) {
+	s = s.clone()
+	s.masks = append([]uint32(nil), s.masks...)
+	if s.masks == nil {
+		s.masks = make([]uint32, len(s.cells))
+	}
+	if s.masks[c] != 0 {
+		mask &= s.masks[c]
+	}
+	s.masks[c] = mask
+	if mask == 0 {
+		return s, ErrConflict
+	}
+	return s, propagator{&s, nil}.restrictTo(c, mask)
+}
+
+// blank returns a sudoku of the receiver's layout without any values, but
+// with its masks applied.
+func (s Sudoku) blank() (Sudoku, error) {
+	res, err := s.shape().empty()
+	if err != nil {
+		return res, err
+	}
+	res.masks = s.masks
+	p := propagator{&res, nil}
+	for c, mask := range s.masks {
+		if mask == 0 {
+			continue
+		}
+		if err := p.restrictTo(coordinate(c), mask); err != nil {
+			return res, err
+		}
+	}
+	return res, nil
+}
+
+// withClue works like withAssignment, but additionally remembers the value as
+// a clue.
+func (s Sudoku) withClue(c coordinate, sv uint8) (Sudoku, error) {
+	s, err := s.withAssignment(c, sv)
+	s.clues[c] = sv
+	return s, err
 }
 
 func (s Sudoku) withAssignment(c coordinate, sv uint8) (Sudoku, error) {
-	if es, ok := s.cells[c].(emptySquare); ok && !es.isValuePossible(sv) {
+	return s.withCountedAssignment(c, sv, nil)
+}
+
+// withCountedAssignment works like withAssignment, and additionally
+// increments count (if not nil) for every square filled in, including the
+// ones filled in by propagation.
+func (s Sudoku) withCountedAssignment(c coordinate, sv uint8, count *int) (Sudoku, error) {
+	s = s.clone()
+	return s, propagator{&s, count}.assign(c, sv)
+}
+
+// A propagator does the work for withAssignment, modifying the sudoku in
+// place. It must only be used on fresh clones. Constraints use it to
+// propagate their own rules, see Constraint.
+type propagator struct {
+	s *Sudoku
+	// count is incremented for every square filled in, if not nil.
+	count *int
+}
+
+// assign fills in the square at c, eliminates sv from its peers and lets the
+// constraints propagate it.
+func (p propagator) assign(c coordinate, sv uint8) error {
+	if es, ok := p.s.cells[c].(emptySquare); ok && !es.isValuePossible(sv) {
 		// field is empty, but can't take that value
-		return s, ErrConflict
+		return ErrConflict
+	}
+	p.s.cells[c] = filledOutSquare(sv)
+	if p.count != nil {
+		*p.count++
 	}
-	s.cells[c] = filledOutSquare(sv)
 
-	for peerC := range peers[c] {
-		peer := s.cells[peerC]
+	for _, peerC := range p.s.layout.peers[c] {
+		if err := p.eliminate(peerC, sv); err != nil {
+			return err
+		}
+	}
+	for _, con := range p.s.layout.constraints {
+		if err := con.propagate(p, c, sv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-		switch sq := peer.(type) {
-		case filledOutSquare:
-			if uint8(sq) == sv {
-				// conflict, we are asked to remove the value we already have
-				return s, ErrConflict
-			}
-		case emptySquare:
-			newsq := sq.eliminated(sv)
-			if fos, ok := newsq.(filledOutSquare); ok {
-				// Propagate
-				var err error
-				if s, err = s.withAssignment(peerC, uint8(fos)); err != nil {
-					return s, err
-				}
-			} else {
-				// just assign the changed field
-				s.cells[peerC] = newsq
-			}
+// eliminate removes sv from the possible values of the square at c. If there
+// is only one value left, the square is filled in with it.
+func (p propagator) eliminate(c coordinate, sv uint8) error {
+	switch sq := p.s.cells[c].(type) {
+	case filledOutSquare:
+		if uint8(sq) == sv {
+			// conflict, we are asked to remove the value we already have
+			return ErrConflict
 		}
+	case emptySquare:
+		newsq := sq.eliminated(sv, p.s.layout.size)
+		if fos, ok := newsq.(filledOutSquare); ok {
+			// Propagate
+			return p.assign(c, uint8(fos))
+		}
+		// just assign the changed field
+		p.s.cells[c] = newsq
 	}
-	return s, nil
+	return nil
+}
+
+// restrictTo eliminates all values not in mask from the square at c.
+func (p propagator) restrictTo(c coordinate, mask uint32) error {
+	for _, sv := range valuesOf(p.possible(c) &^ mask) {
+		if err := p.eliminate(c, sv); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // Output
@@ -230,94 +915,200 @@ func (s Sudoku) withAssignment(c coordinate, sv uint8) (Sudoku, error) {
 // AsInts returns the receiver as a 9x9 grid suitable for display. Any
 // non-filled cells are returned as zero (0). The returned grid is not
 // connected to the internal data structures and may be modified freely.
+//
+// AsInts panics for sudokus that are not 9x9, use Values for those.
 func (s Sudoku) AsInts() [9][9]uint8 {
+	if s.shape().size != 9 {
+		panic("sudoku: AsInts called on a sudoku that is not 9x9")
+	}
+
 	var res [9][9]uint8
-	for i := range s.cells {
-		var cellValue uint8
+	for r, row := range s.Values() {
+		copy(res[r][:], row)
+	}
+	return res
+}
 
-		switch sq := s.cells[i].(type) {
-		case filledOutSquare:
-			cellValue = uint8(sq)
-		default:
-			cellValue = 0
+// Values returns the receiver as a grid of any size, see AsInts.
+func (s Sudoku) Values() [][]uint8 {
+	l := s.shape()
+	res := make([][]uint8, l.size)
+	for r := range res {
+		res[r] = make([]uint8, l.size)
+		for c := range res[r] {
+			if sq, ok := s.square(l.coord(r, c)).(filledOutSquare); ok {
+				res[r][c] = uint8(sq)
+			}
 		}
-		res[i/9][i%9] = cellValue
 	}
 	return res
 }
 
 // String gives the underlying sudoku as a string, with lines separating the
-// blocks.  See the examples for the structure.
+// blocks.  See the examples for the structure. Some variants additionally
+// mark squares, see Diagonals for an example, describe their rules below the
+// grid, see Killer, or show clues around it, see Sandwiches. The marks are
+// ignored by Parse, the clues around the grid are not. Empty squares
+// restricted to some values are written with those values in square
+// brackets, see WithCandidatesAt, so that Parse reads them again. The other
+// squares are padded to the same width then.
 func (s Sudoku) String() string {
-	var res string
+	l := s.shape()
+	res := l.grid(func(c coordinate) string {
+		if sv := s.value(c); sv != 0 || s.masks == nil || s.masks[c] == 0 {
+			return string(l.digits[sv])
+		}
+		symbol := "["
+		for _, sv := range valuesOf(s.masks[c]) {
+			symbol += string(l.digits[sv])
+		}
+		return symbol + "]"
+	}, true)
 
-	for r := 'A'; r <= 'I'; r++ {
-		for c := '1'; c <= '9'; c++ {
-			switch sq := s.cells[coord(r, c)].(type) {
-			case filledOutSquare:
-				res += fmt.Sprintf("%v", uint8(sq))
-			default:
-				res += "."
+	for _, con := range l.constraints {
+		if a, ok := con.(appender); ok {
+			res += "\n" + a.appendix(l)
+		}
+	}
+	return res
+}
+
+// grid draws a symbol for every square, with lines separating the boxes. If
+// marks is set, the marks of the constraints are drawn as well. Symbols that
+// are shorter than others are padded with spaces, so that the lines stay
+// aligned.
+func (l *layout) grid(symbol func(c coordinate) string, marks bool) string {
+	symbols := make([]string, l.numCells)
+	width := 1
+	for c := range symbols {
+		symbols[c] = symbol(coordinate(c))
+		if len(symbols[c]) > width {
+			width = len(symbols[c])
+		}
+	}
+	for c := range symbols {
+		symbols[c] += strings.Repeat(" ", width-len(symbols[c]))
+	}
+	symbol = func(c coordinate) string {
+		return symbols[c]
+	}
+
+	if _, regular := l.constraints[2].(boxes); !regular {
+		return l.irregularGrid(symbol, width, marks)
+	}
+
+	separator := strings.Repeat("-", (width+1)*l.box)
+	separator = strings.Repeat(separator+"+", l.box-1) + separator + "\n"
+
+	columns, rows := l.frame(marks)
+	var res string
+	var positions []int
+	for r := 0; r < l.size; r++ {
+		for c := 0; c < l.size; c++ {
+			if r == 0 {
+				positions = append(positions, len(res))
 			}
+			res += symbol(l.coord(r, c))
+
+			mark, marked := l.mark(l.coord(r, c), marks)
 			switch {
-			case c == '9':
-				res += "\n"
-			case (c-'0')%3 == 0:
-				res += " |"
+			case c == l.size-1 && (marked || rows != nil):
+				res += string(mark) + beside(rows, r) + "\n"
+			case c == l.size-1:
+				res = strings.TrimRight(res, " ") + "\n"
+			case (c+1)%l.box == 0:
+				res += string(mark) + "|"
 			default:
-				res += " "
+				res += string(mark)
 			}
 		}
-		if r == 'C' || r == 'F' {
-			res += "------+------+------\n"
+		if (r+1)%l.box == 0 && r != l.size-1 {
+			res += separator
 		}
 	}
-	return res
-}
-
-// Coordinates are represented by bytes, they are the indices in the cells
-// array.
-type coordinate uint8
-
-func coord(r, c rune) coordinate {
-	return coordinate(uint8(r-'A')*9 + uint8(c-'1'))
+	return above(columns, positions) + res
 }
 
-// Peers Calculation
+// irregularGrid works like grid for irregular boxes, see Jigsaw. Squares of
+// different boxes are separated by '|' within a row, and by '-' between
+// rows. All symbols have the given width.
+func (l *layout) irregularGrid(symbol func(c coordinate) string, width int, marks bool) string {
+	border := func(a, b coordinate) bool {
+		return l.unitsOf[a][2] != l.unitsOf[b][2]
+	}
 
-// A peer is any cell that is influenced by the key, for example A1 is peer of
-// A2, A3, B1, B3 etc, but not of D9.
-var peers = make(map[coordinate]map[coordinate]struct{})
+	columns, rows := l.frame(marks)
+	var res string
+	var positions []int
+	for r := 0; r < l.size; r++ {
+		var line string
+		for c := 0; c < l.size; c++ {
+			cc := l.coord(r, c)
+			if r == 0 {
+				positions = append(positions, len(res))
+			}
+			res += symbol(cc)
 
-func addPeersFor(r, c rune) {
-	cr := coord(r, c)
+			mark, marked := l.mark(cc, marks)
+			switch {
+			case c == l.size-1 && (marked || rows != nil):
+				res += string(mark) + beside(rows, r) + "\n"
+			case c == l.size-1:
+				res = strings.TrimRight(res, " ") + "\n"
+			case border(cc, cc+1):
+				res += "|"
+			default:
+				res += string(mark)
+			}
 
-	peers[cr] = make(map[coordinate]struct{})
-	for r2 := 'A'; r2 <= 'I'; r2++ {
-		if r2 != r {
-			peers[cr][coord(r2, c)] = struct{}{}
+			if r == l.size-1 {
+				continue
+			}
+			below := cc + coordinate(l.size)
+			switch {
+			case !border(cc, below):
+				line += strings.Repeat(" ", width+1)
+			case c < l.size-1 && border(cc+1, below+1):
+				line += strings.Repeat("-", width+1)
+			default:
+				line += strings.Repeat("-", width) + " "
+			}
+		}
+		if line = strings.TrimRight(line, " "); line != "" {
+			res += line + "\n"
 		}
 	}
-	for c2 := '1'; c2 <= '9'; c2++ {
-		if c2 != c {
-			peers[cr][coord(r, c2)] = struct{}{}
+	return above(columns, positions) + res
+}
+
+// above writes the clues of the columns from top to bottom, so that their
+// last digits are right above the first row, at the given positions.
+func above(columns []string, positions []int) string {
+	height := 0
+	for _, clue := range columns {
+		if len(clue) > height {
+			height = len(clue)
 		}
 	}
-	rowOffset := (r - 'A') % 3
-	colOffset := (c - '1') % 3
-	for r2 := r - rowOffset; r2 <= r-rowOffset+2; r2++ {
-		for c2 := c - colOffset; c2 <= c-colOffset+2; c2++ {
-			if r2 != r && c2 != c {
-				peers[cr][coord(r2, c2)] = struct{}{}
+
+	var res string
+	for i := 0; i < height; i++ {
+		line := []byte(strings.Repeat(" ", positions[len(positions)-1]+1))
+		for c, clue := range columns {
+			if j := i - (height - len(clue)); j >= 0 {
+				line[positions[c]] = clue[j]
 			}
 		}
+		res += strings.TrimRight(string(line), " ") + "\n"
 	}
+	return res
 }
 
-func init() {
-	for r := 'A'; r <= 'I'; r++ {
-		for c := '1'; c <= '9'; c++ {
-			addPeersFor(r, c)
-		}
+// beside returns what is written after the given row, the clue of the row
+// if there is one.
+func beside(rows []string, r int) string {
+	if rows == nil || rows[r] == "" {
+		return ""
 	}
+	return "  " + rows[r]
 }
@@ -1,9 +1,14 @@
 package sudoku
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"sort"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestSquare(t *testing.T) {
@@ -14,14 +19,14 @@ func TestSquare(t *testing.T) {
 		t.Error("Expected no eliminated values")
 	}
 
-	s = s.(emptySquare).eliminated(1)
+	s = s.(emptySquare).eliminated(1, 9)
 
 	if s.(emptySquare).eliminatedValues != (1 << 1) {
 		t.Error("Expected to have eliminated 1, but was", s.(emptySquare).eliminatedValues)
 	}
 
 	for i := uint8(1); i <= uint8(8); i++ {
-		s = s.(emptySquare).eliminated(i)
+		s = s.(emptySquare).eliminated(i, 9)
 	}
 
 	if val := s.(filledOutSquare); val != 9 {
@@ -30,7 +35,7 @@ func TestSquare(t *testing.T) {
 }
 
 func TestGlobals(t *testing.T) {
-	if len(peers[coord('A', '2')]) != 20 {
+	if len(layouts[3].peers[coord('A', '2')]) != 20 {
 		t.Error("Not 20 peers")
 	}
 }
@@ -271,3 +276,365 @@ func TestRejectsConflictsViaAPI(t *testing.T) {
 		t.Error("Should return error")
 	}
 }
+
+func TestParseError(t *testing.T) {
+	typo := `53..7....
+6..195...
+.98....6.
+8...6...5
+4..8.3..1
+7...2...6
+.6....28.
+...419..5
+....8..79`
+	_, err := Parse(typo)
+	expected := &ParseError{Offset: 78, Line: 8, Column: 9, Cell: "H9", Cells: 71, Digit: '5', Peer: "D9", Err: ErrConflict}
+	if actual, ok := err.(*ParseError); !ok || *actual != *expected {
+		t.Errorf("Expected %#v, but got %#v", expected, err)
+	}
+	if !errors.Is(err, ErrConflict) {
+		t.Error("Expected the error to be a conflict, but got", err)
+	}
+	if msg := err.Error(); msg != "line 8, column 9: Conflict in H9, 5 is already in D9" {
+		t.Error("Unexpected message", msg)
+	}
+
+	_, err = Parse("1 2\n")
+	expected = &ParseError{Offset: 4, Line: 2, Column: 1, Cell: "A3", Cells: 2, Err: io.ErrUnexpectedEOF}
+	if actual, ok := err.(*ParseError); !ok || *actual != *expected {
+		t.Errorf("Expected %#v, but got %#v", expected, err)
+	}
+	_, err = Parse("[13")
+	expected = &ParseError{Offset: 3, Line: 1, Column: 4, Cell: "A1", Err: io.ErrUnexpectedEOF}
+	if actual, ok := err.(*ParseError); !ok || *actual != *expected {
+		t.Errorf("Expected %#v for unterminated candidates, but got %#v", expected, err)
+	}
+	if _, err = Parse(" \n"); err != io.EOF {
+		t.Error("Expected io.EOF for empty input, but got", err)
+	}
+}
+
+func TestParseStrict(t *testing.T) {
+	strict := ParseOptions{Strict: true}
+	grid := `5 3 . |. 7 . |. . .
+6 . . |1 9 5 |. . .
+. 9 8 |. . . |. 6 .
+------+------+------
+8 . . |. 6 . |. . 3
+4 . . |8 . 3 |. . 1
+7 . . |. 2 . |. . 6
+------+------+------
+. 6 . |. . . |2 8 .
+. . . |4 1 9 |. . 5
+. . . |. 8 . |. 7 9
+`
+	if _, err := strict.Parse(grid); err != nil {
+		t.Error("Expected the output of String to be accepted, but got", err)
+	}
+	if _, err := strict.Parse("[13579]" + strings.Repeat(".", 80)); err != nil {
+		t.Error("Expected candidates to be accepted, but got", err)
+	}
+
+	cases := []struct {
+		input    string
+		expected ParseError
+		msg      string
+	}{
+		{"53..7x", ParseError{Offset: 5, Line: 1, Column: 6, Cell: "A6", Cells: 5, Digit: 'x', Err: ErrUnexpectedRune},
+			`line 1, column 6: Unexpected character 'x' in A6`},
+		{"[1,3]", ParseError{Offset: 2, Line: 1, Column: 3, Cell: "A1", Digit: ',', Err: ErrUnexpectedRune},
+			`line 1, column 3: Unexpected character ',' in A1`},
+		{grid + "1", ParseError{Offset: 222, Line: 12, Column: 1, Cell: "I9", Cells: 81, Err: ErrTrailingSquares},
+			`line 12, column 1: Trailing squares after I9`},
+		{grid + "--\n?", ParseError{Offset: 225, Line: 13, Column: 1, Cells: 81, Digit: '?', Err: ErrUnexpectedRune},
+			`line 13, column 1: Unexpected character '?' after the last square`},
+		{grid[:100], ParseError{Offset: 100, Line: 5, Column: 20, Cell: "E1", Cells: 36, Err: io.ErrUnexpectedEOF},
+			`line 5, column 20: unexpected EOF in E1, after 36 squares`},
+	}
+	for _, x := range cases {
+		_, err := strict.Parse(x.input)
+		if actual, ok := err.(*ParseError); !ok || *actual != x.expected {
+			t.Errorf("Expected %#v for %q, but got %#v", x.expected, x.input, err)
+		} else if actual.Error() != x.msg {
+			t.Errorf("Expected %q, but got %q", x.msg, actual.Error())
+		}
+	}
+
+	// the lenient default ignores all of this
+	for _, input := range []string{"53..7x" + strings.Repeat(".", 76), grid + "1"} {
+		if _, err := Parse(input); err != nil {
+			t.Error("Expected", input, "to be accepted, but got", err)
+		}
+	}
+}
+
+func TestCountSolutions(t *testing.T) {
+	unique, err := Parse("85...24..72......9..4.........1.7..23.5...9...4...........8..7..17..........36.4.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := unique.CountSolutions(0); n != 1 {
+		t.Error("Expected exactly one solution, but got", n)
+	}
+	if !unique.IsUnique() {
+		t.Error("Expected sudoku to be unique")
+	}
+
+	// same as above, but without B1
+	ambiguous, err := Parse("85...24...2......9..4.........1.7..23.5...9...4...........8..7..17..........36.4.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := ambiguous.CountSolutions(0); n != 6 {
+		t.Error("Expected 6 solutions, but got", n)
+	}
+	if n := ambiguous.CountSolutions(4); n != 4 {
+		t.Error("Expected counting to stop at 4, but got", n)
+	}
+	if ambiguous.IsUnique() {
+		t.Error("Expected sudoku not to be unique")
+	}
+
+	// parses fine, but 1 is wrong in A3
+	unsolvable, err := Parse("851..24..72......9..4.........1.7..23.5...9...4...........8..7..17..........36.4.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := unsolvable.CountSolutions(0); n != 0 {
+		t.Error("Expected no solution, but got", n)
+	}
+	if unsolvable.IsUnique() {
+		t.Error("Expected sudoku without solution not to be unique")
+	}
+}
+
+func TestForEachSolution(t *testing.T) {
+	s, err := Parse("85...24...2......9..4.........1.7..23.5...9...4...........8..7..17..........36.4.")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seen := make(map[[9][9]uint8]bool)
+	s.ForEachSolution(func(solution Sudoku) bool {
+		assertIsValidSudoku(solution, t)
+		seen[solution.AsInts()] = true
+		return true
+	})
+	if len(seen) != 6 {
+		t.Error("Expected 6 distinct solutions, but got", len(seen))
+	}
+
+	calls := 0
+	s.ForEachSolution(func(Sudoku) bool {
+		calls++
+		return calls < 2
+	})
+	if calls != 2 {
+		t.Error("Expected enumeration to stop after 2 solutions, but got", calls)
+	}
+}
+
+func ExampleSudoku_ForEachSolution() {
+	s, err := Parse("85...24...2......9..4.........1.7..23.5...9...4...........8..7..17..........36.4.")
+	if err != nil {
+		panic(err)
+	}
+
+	var first Sudoku
+	count := 0
+	s.ForEachSolution(func(solution Sudoku) bool {
+		if count == 0 {
+			first = solution
+		}
+		count++
+		return true
+	})
+	fmt.Println(count, "solutions, the first one is:")
+	fmt.Print(first)
+	// Output:
+	// 6 solutions, the first one is:
+	// 8 5 9 |6 1 2 |4 3 7
+	// 1 2 3 |8 7 4 |5 6 9
+	// 7 6 4 |3 5 9 |1 2 8
+	// ------+------+------
+	// 9 8 6 |1 4 7 |3 5 2
+	// 3 7 5 |2 6 8 |9 1 4
+	// 2 4 1 |5 9 3 |7 8 6
+	// ------+------+------
+	// 4 3 2 |9 8 1 |6 7 5
+	// 6 1 7 |4 2 5 |8 9 3
+	// 5 9 8 |7 3 6 |2 4 1
+}
+
+func TestSolveContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := (Sudoku{}).SolveContext(ctx)
+	if err != context.Canceled {
+		t.Error("Expected context.Canceled, but got", err)
+	}
+}
+
+func TestSolveContextDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+
+	_, err := (Sudoku{}).SolveContext(ctx)
+	if err != context.DeadlineExceeded {
+		t.Error("Expected context.DeadlineExceeded, but got", err)
+	}
+}
+
+func TestSolveContextFinishes(t *testing.T) {
+	s, err := Parse("85...24..72......9..4.........1.7..23.5...9...4...........8..7..17..........36.4.")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	solved, err := s.SolveContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertIsValidSudoku(solved, t)
+}
+
+func TestSolveWithStats(t *testing.T) {
+	s, err := Parse("85...24..72......9..4.........1.7..23.5...9...4...........8..7..17..........36.4.")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	solved, stats, err := s.SolveWithStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertIsValidSudoku(solved, t)
+
+	empty := 0
+	for _, row := range s.AsInts() {
+		for _, v := range row {
+			if v == 0 {
+				empty++
+			}
+		}
+	}
+
+	if stats.Assignments < empty {
+		t.Error("Expected at least", empty, "assignments, but got", stats.Assignments)
+	}
+	if stats.Guesses == 0 || stats.Backtracks == 0 {
+		t.Error("Expected the hardest sudoku to need guessing, but got", stats)
+	}
+	if stats.Backtracks >= stats.Guesses {
+		t.Error("Expected fewer backtracks than guesses, but got", stats)
+	}
+	if stats.MaxDepth == 0 || stats.MaxDepth >= stats.Nodes {
+		t.Error("Expected depth to be within the number of nodes, but got", stats)
+	}
+
+	_, again, _ := s.SolveWithStats()
+	if again != stats {
+		t.Error("Expected stable statistics, but got", stats, "and", again)
+	}
+}
+
+func TestSolveWithStatsWithoutSearch(t *testing.T) {
+	solved, err := Parse("85...24..72......9..4.........1.7..23.5...9...4...........8..7..17..........36.4.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if solved, err = solved.Solve(); err != nil {
+		t.Fatal(err)
+	}
+
+	_, stats, err := solved.SolveWithStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats != (Stats{Nodes: 1}) {
+		t.Error("Expected a single node for a solved sudoku, but got", stats)
+	}
+}
+
+const oddEven = "5.[13579]..2....2[2468][13579].....4.[2468]...[13579]6..4....[2468]3....[13579]5[13579].9.3.[13579]7..[13579]..2[2468]..3.7.[13579].9.[2468][13579]...[13579].[13579].96..[2468]8"
+
+func TestMasks(t *testing.T) {
+	s, err := Parse(oddEven)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.IsUnique() {
+		t.Error("Expected a unique solution")
+	}
+	if plain, _ := Parse(strings.NewReplacer("[13579]", ".", "[2468]", ".").Replace(oddEven)); plain.IsUnique() {
+		t.Error("Expected several solutions without the masks")
+	}
+
+	solved, err := s.Solve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := Parse("513682974926347851478519362147896235682153497359724186265438719891275643734961528")
+	if solved.AsInts() != expected.AsInts() {
+		t.Error("Expected\n", expected, "but got\n", solved)
+	}
+
+	minimal, err := s.Minimize(MinimizeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !minimal.IsUnique() {
+		t.Error("Expected the masks to be kept by Minimize")
+	}
+	// the box lines stay aligned with the candidates in between
+	lines := strings.Split(strings.TrimSpace(minimal.String()), "\n")
+	for _, line := range lines {
+		if strings.IndexAny(line, "|+") != strings.IndexAny(lines[0], "|+") {
+			t.Error("Expected the lines to be aligned, but got\n", minimal)
+			break
+		}
+	}
+	again, err := Parse(minimal.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clues, _ := again.cluesOnly(); clues.String() != minimal.String() {
+		t.Error("Expected the masks to be read again, but got\n", again)
+	}
+	if solved, _ := again.Solve(); solved.AsInts() != expected.AsInts() {
+		t.Error("Expected the same solution after reading the masks again, but got\n", solved)
+	}
+	if _, err := s.Explain(); err != nil && err != ErrStuck {
+		t.Error("Expected the masks to be used by Explain, but got", err)
+	}
+}
+
+func TestWithCandidatesAt(t *testing.T) {
+	s, err := Sudoku{}.WithCandidatesAt(0, 0, 2, 4, 6, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, err = s.WithCandidatesAt(0, 0, 1, 2, 3); err != nil {
+		t.Fatal(err)
+	}
+	if v := s.AsInts()[0][0]; v != 2 {
+		t.Error("Expected A1 to be 2, but got", v)
+	}
+	if _, err := s.WithCandidatesAt(0, 0, 4); err != ErrConflict {
+		t.Error("Expected ErrConflict without any candidate left, but got", err)
+	}
+	if _, err := s.WithCandidatesAt(0, 9, 4); err != ErrConflict {
+		t.Error("Expected ErrConflict outside of the board, but got", err)
+	}
+	if _, err := Parse("[]" + strings.Repeat(".", 80)); !errors.Is(err, ErrConflict) {
+		t.Error("Expected ErrConflict for an empty mask, but got", err)
+	}
+	if _, err := Parse("1[1]" + strings.Repeat(".", 79)); !errors.Is(err, ErrConflict) {
+		t.Error("Expected ErrConflict for a mask ruled out by a peer, but got", err)
+	}
+}
@@ -0,0 +1,218 @@
+package sudoku
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Variants
+
+// A Variant is a well-known set of additional rules. Variants can be
+// combined, for example XSudoku|Windoku.
+type Variant uint16
+
+const (
+	// XSudoku requires both main diagonals to contain every value once.
+	XSudoku Variant = 1 << iota
+	// Windoku requires the windows, the regions between the boxes, to
+	// contain every value once.
+	Windoku
+	// AntiKnight forbids equal values a knight's move apart.
+	AntiKnight
+	// AntiKing forbids equal values a king's move apart, that is diagonally
+	// adjacent ones.
+	AntiKing
+	// NonConsecutive forbids consecutive values in orthogonally adjacent
+	// squares.
+	NonConsecutive
+)
+
+var variantNames = [...]string{
+	"X",
+	"Windoku",
+	"AntiKnight",
+	"AntiKing",
+	"NonConsecutive",
+}
+
+var variantConstraints = [...]Constraint{
+	Diagonals,
+	Windows,
+	KnightMoves,
+	KingMoves,
+	NonConsecutiveNeighbours,
+}
+
+// String returns the names of the variants joined by "+", "Standard" if
+// there are none.
+func (v Variant) String() string {
+	var names []string
+	for i, name := range variantNames {
+		if v&(1<<uint(i)) != 0 {
+			names = append(names, name)
+		}
+	}
+	if rest := v &^ (1<<uint(len(variantNames)) - 1); rest != 0 {
+		names = append(names, fmt.Sprintf("Variant(%d)", uint16(rest)))
+	}
+	if len(names) == 0 {
+		return "Standard"
+	}
+	return strings.Join(names, "+")
+}
+
+// ParseVariant returns the variant with the given name, as returned by
+// String. Case is ignored.
+func ParseVariant(name string) (Variant, bool) {
+	var res Variant
+	for _, part := range strings.Split(name, "+") {
+		found := false
+		for i, n := range variantNames {
+			if strings.EqualFold(n, part) {
+				res |= 1 << uint(i)
+				found = true
+			}
+		}
+		if !found && !strings.EqualFold(part, "Standard") {
+			return 0, false
+		}
+	}
+	return res, true
+}
+
+// constraints returns the constraints the variant adds to the classic rules.
+func (v Variant) constraints() []Constraint {
+	var res []Constraint
+	for i, con := range variantConstraints {
+		if v&(1<<uint(i)) != 0 {
+			res = append(res, con)
+		}
+	}
+	return res
+}
+
+// Diagonals requires both main diagonals to contain every value once, see
+// XSudoku. In the output of String, the squares of the diagonals are marked
+// with '\' and '/', or '*' where they cross.
+var Diagonals Constraint = diagonals{}
+
+type diagonals struct {
+	unitConstraint
+}
+
+func (diagonals) units(l *layout) [][]coordinate {
+	res := make([][]coordinate, 2)
+	for i := 0; i < l.size; i++ {
+		res[0] = append(res[0], l.coord(i, i))
+		res[1] = append(res[1], l.coord(i, l.size-1-i))
+	}
+	return res
+}
+
+func (diagonals) unitName(l *layout, i int) string {
+	return [...]string{"main diagonal", "anti-diagonal"}[i]
+}
+
+func (diagonals) mark(l *layout, c coordinate) (byte, bool) {
+	row, col := int(c)/l.size, int(c)%l.size
+	main, anti := row == col, row+col == l.size-1
+	switch {
+	case main && anti:
+		return '*', true
+	case main:
+		return '\\', true
+	case anti:
+		return '/', true
+	}
+	return 0, false
+}
+
+// Windows requires the windows of a Windoku to contain every value once. The
+// windows have the size of a box and are placed between the boxes, one square
+// away from the border and from each other, four of them on a 9x9 board. In
+// the output of String, their squares are marked with '#'.
+var Windows Constraint = windows{}
+
+type windows struct {
+	unitConstraint
+}
+
+// offsets returns the first row and column of the windows.
+func (windows) offsets(l *layout) []int {
+	var res []int
+	for o := 1; o+l.box < l.size; o += l.box + 1 {
+		res = append(res, o)
+	}
+	return res
+}
+
+func (w windows) units(l *layout) [][]coordinate {
+	var res [][]coordinate
+	offsets := w.offsets(l)
+	for _, row := range offsets {
+		for _, col := range offsets {
+			var unit []coordinate
+			for i := 0; i < l.size; i++ {
+				unit = append(unit, l.coord(row+i/l.box, col+i%l.box))
+			}
+			res = append(res, unit)
+		}
+	}
+	return res
+}
+
+func (windows) unitName(l *layout, i int) string {
+	return fmt.Sprintf("window %d", i+1)
+}
+
+func (w windows) mark(l *layout, c coordinate) (byte, bool) {
+	inWindow := func(i int) bool {
+		for _, o := range w.offsets(l) {
+			if i >= o && i < o+l.box {
+				return true
+			}
+		}
+		return false
+	}
+	if inWindow(int(c)/l.size) && inWindow(int(c)%l.size) {
+		return '#', true
+	}
+	return 0, false
+}
+
+// The constraints of chess variants, see AntiKnight and AntiKing.
+var (
+	// KnightMoves forbids equal values a knight's move apart.
+	KnightMoves Constraint = chessMoves{{-2, -1}, {-2, 1}, {-1, -2}, {-1, 2}, {1, -2}, {1, 2}, {2, -1}, {2, 1}}
+	// KingMoves forbids equal values a king's move apart. Orthogonally
+	// adjacent squares share a row or column anyway.
+	KingMoves Constraint = chessMoves{{-1, -1}, {-1, 1}, {1, -1}, {1, 1}}
+)
+
+// chessMoves makes the squares reachable by one of the moves peers, given as
+// offsets of row and column.
+type chessMoves [][2]int
+
+func (chessMoves) units(l *layout) [][]coordinate {
+	return nil
+}
+
+func (m chessMoves) peers(l *layout, c coordinate) []coordinate {
+	var res []coordinate
+	row, col := int(c)/l.size, int(c)%l.size
+	for _, move := range m {
+		r, c := row+move[0], col+move[1]
+		if r >= 0 && r < l.size && c >= 0 && c < l.size {
+			res = append(res, l.coord(r, c))
+		}
+	}
+	return res
+}
+
+func (chessMoves) restrict(p propagator) error {
+	return nil
+}
+
+func (chessMoves) propagate(p propagator, c coordinate, sv uint8) error {
+	return nil
+}
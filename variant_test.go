@@ -0,0 +1,243 @@
+package sudoku
+
+import (
+	"strings"
+	"testing"
+)
+
+const xSudoku = "...8............42.92.6..5...7.46...............95.1...1..8.93.72............5..."
+
+func TestXSudoku(t *testing.T) {
+	s, err := ParseOptions{Variant: XSudoku}.Parse(xSudoku)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.IsUnique() {
+		t.Error("Expected a unique solution")
+	}
+	if classic, _ := Parse(xSudoku); classic.IsUnique() {
+		t.Error("Expected several solutions without the diagonals")
+	}
+
+	solved, err := s.Solve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertIsSolved(solved, t)
+
+	expected := `5\7 4 |8 2 3 |6 9 1/
+1 8\6 |5 7 9 |3 4/2
+3 9 2\|4 6 1 |7/5 8
+------+------+------
+2 3 7 |1\4 6/|5 8 9
+9 5 1 |2 3*8 |4 7 6
+6 4 8 |9/5 7\|1 2 3
+------+------+------
+4 1 5/|6 8 2 |9\3 7
+7 2/9 |3 1 4 |8 6\5
+8/6 3 |7 9 5 |2 1 4\
+`
+	if actual := solved.String(); actual != expected {
+		t.Error("Expected\n", expected, "but got\n", actual)
+	}
+
+	again, err := ParseOptions{Variant: XSudoku}.Parse(solved.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.String() != expected {
+		t.Error("Expected the marks to be ignored when parsing, but got\n", again)
+	}
+}
+
+func TestXSudokuConflict(t *testing.T) {
+	s, err := ParseOptions{Variant: XSudoku}.Parse(xSudoku)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A1 and I9 share the main diagonal
+	if _, err := s.WithCellValued('A', '1', 4); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.WithCellValued('I', '9', 5); err != ErrConflict {
+		t.Error("Expected ErrConflict on the diagonal, but got", err)
+	}
+}
+
+func TestXSudokuExplain(t *testing.T) {
+	s, err := ParseOptions{Variant: XSudoku}.Parse(xSudoku)
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps, _ := s.Explain()
+	for _, st := range steps {
+		if st.String() == "Hidden Single: 5 can only go into A1 in main diagonal." {
+			return
+		}
+	}
+	t.Error("Expected the diagonal to be used, but got", steps)
+}
+
+const windoku = "96.4............4.1...........2..86..8..........91..5.2..............6..7.5.....3"
+
+func TestWindoku(t *testing.T) {
+	s, err := ParseOptions{Variant: Windoku}.Parse(windoku)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.IsUnique() {
+		t.Error("Expected a unique solution")
+	}
+	if classic, _ := Parse(windoku); classic.IsUnique() {
+		t.Error("Expected several solutions without the windows")
+	}
+
+	solved, err := s.Solve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertIsSolved(solved, t)
+
+	expected := `9 6 2 |4 5 7 |1 3 8
+3 7#8#|6#9 1#|5#4#2
+1 5#4#|3#8 2#|9#7#6
+------+------+------
+5 1#9#|2#4 3#|8#6#7
+4 8 3 |7 6 5 |2 9 1
+6 2#7#|9#1 8#|3#5#4
+------+------+------
+2 4#6#|8#3 9#|7#1#5
+8 3#1#|5#7 4#|6#2#9
+7 9 5 |1 2 6 |4 8 3
+`
+	if actual := solved.String(); actual != expected {
+		t.Error("Expected\n", expected, "but got\n", actual)
+	}
+
+	steps, _ := s.Explain()
+	for _, st := range steps {
+		if st.String() == "Hidden Single: 9 can only go into D3 in window 1." {
+			return
+		}
+	}
+	t.Error("Expected the windows to be used, but got", steps)
+}
+
+func TestWindokuSizes(t *testing.T) {
+	for box, windows := range map[int]int{2: 1, 3: 4, 4: 9, 5: 16} {
+		l, err := ParseOptions{BoxSize: box, Variant: Windoku}.layout()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := len(Windows.units(l)); actual != windows {
+			t.Error("Expected", windows, "windows for box size", box, "but got", actual)
+		}
+		if box > 3 {
+			continue
+		}
+		if _, err := (Sudoku{layout: l}).Solve(); err != nil {
+			t.Error("Expected an empty windoku of box size", box, "to be solvable, but got", err)
+		}
+	}
+}
+
+func TestVariantString(t *testing.T) {
+	cases := map[Variant]string{
+		0:                 "Standard",
+		XSudoku:           "X",
+		XSudoku | 128:     "X+Variant(128)",
+		XSudoku | Windoku: "X+Windoku",
+	}
+	for v, expected := range cases {
+		if actual := v.String(); actual != expected {
+			t.Error("Expected", expected, "but got", actual)
+		}
+	}
+
+	if v, ok := ParseVariant("x"); !ok || v != XSudoku {
+		t.Error("Expected to parse X, but got", v, ok)
+	}
+	if v, ok := ParseVariant("windoku+X"); !ok || v != XSudoku|Windoku {
+		t.Error("Expected to parse windoku+X, but got", v, ok)
+	}
+	if _, ok := ParseVariant("y"); ok {
+		t.Error("Expected unknown variants to be rejected")
+	}
+}
+
+func TestChessVariants(t *testing.T) {
+	cases := []struct {
+		variant  Variant
+		sudoku   string
+		expected string
+	}{
+		{AntiKnight, "..4...1............................1...6.4.523...7.............2..3......6..9....",
+			"634529187781463529529187346496852731178634952352971468945218673217346895863795214"},
+		{AntiKing, "6.4..3....7......1...4........3....6...9621..........2.........2.1...7.88....5...",
+			"654173829372859461189426537725318946438962175916547382547281693291634758863795214"},
+	}
+	for _, c := range cases {
+		s, err := ParseOptions{Variant: c.variant}.Parse(c.sudoku)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !s.IsUnique() {
+			t.Error(c.variant, ": expected a unique solution")
+		}
+		if classic, _ := Parse(c.sudoku); classic.IsUnique() {
+			t.Error(c.variant, ": expected several solutions with the classic rules")
+		}
+
+		solved, err := s.Solve()
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, _ := Parse(c.expected)
+		if solved.AsInts() != expected.AsInts() {
+			t.Error(c.variant, ": expected\n", expected, "but got\n", solved)
+		}
+	}
+}
+
+func TestChessMovesPeers(t *testing.T) {
+	l := layouts[3]
+	cases := []struct {
+		con      Constraint
+		c        coordinate
+		expected []coordinate
+	}{
+		{KnightMoves, coord('A', '1'), []coordinate{coord('B', '3'), coord('C', '2')}},
+		{KnightMoves, coord('E', '5'), []coordinate{
+			coord('C', '4'), coord('C', '6'), coord('D', '3'), coord('D', '7'),
+			coord('F', '3'), coord('F', '7'), coord('G', '4'), coord('G', '6'),
+		}},
+		{KingMoves, coord('A', '1'), []coordinate{coord('B', '2')}},
+		{KingMoves, coord('E', '9'), []coordinate{coord('D', '8'), coord('F', '8')}},
+	}
+	for _, c := range cases {
+		actual := c.con.peers(l, c.c)
+		if len(actual) != len(c.expected) {
+			t.Error("Expected peers", c.expected, "of", c.c, "but got", actual)
+			continue
+		}
+		for i := range actual {
+			if actual[i] != c.expected[i] {
+				t.Error("Expected peers", c.expected, "of", c.c, "but got", actual)
+				break
+			}
+		}
+	}
+
+	s, err := ParseOptions{Variant: AntiKnight | AntiKing}.Parse(strings.Repeat(".", 81))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, err = s.WithCellValued('E', '5', 5); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []coordinate{coord('C', '4'), coord('D', '4'), coord('F', '6')} {
+		if s.candidates(c)&(1<<5) != 0 {
+			t.Error("Expected 5 to be eliminated from", c)
+		}
+	}
+}